@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/env"
+	"github.com/allanmaral/go-expert-otel-challenge/internal/webserver"
+)
+
+// newAuthenticator loads the partner API keys from API_KEYS_FILE and
+// API_KEYS. Starting without any key is refused, unless AUTH_DISABLED is
+// set, in which case nil is returned and every request is let through.
+func newAuthenticator(getEnv func(key string) string) (*webserver.Authenticator, error) {
+	disabled, err := env.Bool(getEnv, "AUTH_DISABLED", false)
+	if err != nil {
+		return nil, err
+	}
+	if disabled {
+		return nil, nil
+	}
+
+	store := webserver.NewKeyStore()
+	if path := getEnv("API_KEYS_FILE"); path != "" {
+		if err := store.LoadFile(path); err != nil {
+			return nil, fmt.Errorf("invalid API_KEYS_FILE: %w", err)
+		}
+	}
+	if err := store.ParseKeys(getEnv("API_KEYS")); err != nil {
+		return nil, fmt.Errorf("invalid API_KEYS: %w", err)
+	}
+
+	if store.Len() == 0 {
+		return nil, fmt.Errorf("no API keys configured: set API_KEYS or API_KEYS_FILE, or AUTH_DISABLED=true")
+	}
+	return webserver.NewAuthenticator(store, "input-service"), nil
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"net/http"
 	"os"
@@ -15,8 +14,21 @@ import (
 
 	"go.opentelemetry.io/otel"
 
+	"github.com/allanmaral/go-expert-otel-challenge/internal/env"
 	"github.com/allanmaral/go-expert-otel-challenge/internal/input"
+	"github.com/allanmaral/go-expert-otel-challenge/internal/logging"
 	"github.com/allanmaral/go-expert-otel-challenge/internal/opentelemetry"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/retry"
+)
+
+// The request budget bounds the whole lookup, orchestrator included. The
+// server write timeout leaves some room past it to send the 504 answer.
+const (
+	defaultRequestBudget = 10 * time.Second
+	readHeaderTimeout    = 5 * time.Second
+	readTimeout          = 10 * time.Second
+	writeTimeoutMargin   = 5 * time.Second
+	idleTimeout          = 120 * time.Second
 )
 
 func run(
@@ -28,22 +40,76 @@ func run(
 	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	providerShutdown, err := opentelemetry.InitProvider(ctx, "input-service", getEnv("OTEL_EXPORTER_URL"))
+	otelOpts, err := opentelemetry.OptionsFromEnv(getEnv)
+	if err != nil {
+		return fmt.Errorf("failed to read the OTEL configuration: %w", err)
+	}
+
+	shipLogs := getEnv("OTEL_LOGS_EXPORTER") == "otlp"
+	if shipLogs {
+		otelOpts = append(otelOpts, opentelemetry.WithLogs())
+	}
+
+	telemetry, err := opentelemetry.InitProvider(ctx, "input-service", getEnv("OTEL_EXPORTER_URL"), otelOpts...)
 	if err != nil {
 		return fmt.Errorf("failed to initialize the OTEL provider: %w", err)
 	}
 
-	logger := log.New(stdout, "INPUT: ", log.LstdFlags)
-	tracer := otel.Tracer("input-service")
+	logger := logging.New(stdout, "input-service", shipLogs)
+	meter := otel.Meter("input-service")
+
+	// The forwarded POST is only retried when it never reached the
+	// orchestrator: its error answers come after it already retried the
+	// providers.
+	retryPolicy := retry.DefaultPolicy()
+	retryPolicy.ShouldRetry = retry.ConnectionErrors
+	if retryPolicy.MaxAttempts, err = env.Int(getEnv, "ORCHESTRATOR_RETRY_MAX_ATTEMPTS", retryPolicy.MaxAttempts); err != nil {
+		return err
+	}
+	if retryPolicy.MaxAttempts < 1 {
+		return fmt.Errorf("invalid ORCHESTRATOR_RETRY_MAX_ATTEMPTS: must be at least 1")
+	}
+	budget, err := env.Duration(getEnv, "REQUEST_BUDGET", defaultRequestBudget)
+	if err != nil {
+		return err
+	}
 
-	srv := input.New(logger, tracer, getEnv("ORCHESTRATOR_URL"))
+	auth, err := newAuthenticator(getEnv)
+	if err != nil {
+		return err
+	}
+	if auth == nil {
+		logger.Warn("authentication disabled: AUTH_DISABLED is set")
+	}
+	ipLimiter, err := newIPRateLimiter(getEnv)
+	if err != nil {
+		return err
+	}
+	limiter, err := newRateLimiter(getEnv)
+	if err != nil {
+		return err
+	}
+
+	orchestrator := input.Orchestrator{
+		URL:         getEnv("ORCHESTRATOR_URL"),
+		RetryPolicy: retryPolicy,
+		ServiceKey:  getEnv("SERVICE_API_KEY"),
+	}
+	srv, err := input.New(logger, meter, telemetry, orchestrator, budget, auth, ipLimiter, limiter)
+	if err != nil {
+		return err
+	}
 	httpServer := &http.Server{
-		Addr:    net.JoinHostPort("0.0.0.0", "8080"),
-		Handler: srv,
+		Addr:              net.JoinHostPort("0.0.0.0", "8080"),
+		Handler:           srv,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      budget + writeTimeoutMargin,
+		IdleTimeout:       idleTimeout,
 	}
 
 	go func() {
-		logger.Printf("listening on %s\n", httpServer.Addr)
+		logger.Info("listening", "addr", httpServer.Addr)
 		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			_, _ = fmt.Fprintf(stderr, "error listening and serving: %s\n", err)
 		}
@@ -58,11 +124,11 @@ func run(
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		logger.Printf("shutting http server down...\n")
+		logger.Info("shutting http server down")
 		if err := httpServer.Shutdown(shutdownCtx); err != nil {
 			_, _ = fmt.Fprintf(stderr, "error shutting http server down: %s\n", err)
 		}
-		if err := providerShutdown(shutdownCtx); err != nil {
+		if err := telemetry.Shutdown(shutdownCtx); err != nil {
 			_, _ = fmt.Fprintf(stderr, "error shutting OTEL provider down: %s\n", err)
 		}
 	}()
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/webserver"
+)
+
+const (
+	defaultRateLimit   = "60/1m"
+	defaultIPRateLimit = "120/1m,burst=20"
+)
+
+// newIPRateLimiter limits the authenticated routes to RATE_LIMIT_IP
+// requests per IP, or not at all when it is "none". It runs before the
+// API key is checked, so failed attempts are limited too.
+func newIPRateLimiter(getEnv func(key string) string) (*webserver.RateLimiter, error) {
+	def, err := parseRateLimit(getEnv("RATE_LIMIT_IP"), defaultIPRateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_IP: %w", err)
+	}
+	return webserver.NewRateLimiter(webserver.NewMemoryRateLimitStore(), webserver.RateLimitByIP, def, nil), nil
+}
+
+// newRateLimiter limits every route to RATE_LIMIT requests per client,
+// or not at all when it is "none". RATE_LIMIT_ROUTES overrides it per
+// route pattern, as in "POST /api/weather=10/1s,burst=20;GET /ready=none".
+// Clients are told apart by their authenticated identity, or by IP with
+// RATE_LIMIT_KEY=ip.
+func newRateLimiter(getEnv func(key string) string) (*webserver.RateLimiter, error) {
+	def, err := parseRateLimit(getEnv("RATE_LIMIT"), defaultRateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT: %w", err)
+	}
+
+	// Readiness probes are never limited unless asked to, so a busy
+	// client can not get the service marked as down.
+	routes := map[string]webserver.RateLimit{"GET /ready": {}}
+	if v := getEnv("RATE_LIMIT_ROUTES"); v != "" {
+		for _, entry := range strings.Split(v, ";") {
+			pattern, spec, ok := strings.Cut(entry, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid RATE_LIMIT_ROUTES entry %q: expected <pattern>=<limit>", entry)
+			}
+			limit, err := parseRateLimit(spec, "")
+			if err != nil {
+				return nil, fmt.Errorf("invalid RATE_LIMIT_ROUTES: %w", err)
+			}
+			routes[strings.TrimSpace(pattern)] = limit
+		}
+	}
+
+	var key webserver.RateLimitKeyFunc
+	switch v := getEnv("RATE_LIMIT_KEY"); v {
+	case "", "client":
+		key = webserver.RateLimitByClient
+	case "ip":
+		key = webserver.RateLimitByIP
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_KEY %q", v)
+	}
+
+	return webserver.NewRateLimiter(webserver.NewMemoryRateLimitStore(), key, def, routes), nil
+}
+
+// parseRateLimit reads a limit, where "none" means unlimited and an
+// empty value falls back to def.
+func parseRateLimit(v, def string) (webserver.RateLimit, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		v = def
+	}
+	if v == "" || v == "none" {
+		return webserver.RateLimit{}, nil
+	}
+	return webserver.ParseRateLimit(v)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/env"
+	"github.com/allanmaral/go-expert-otel-challenge/internal/webserver"
+)
+
+// newServiceAuthenticator only lets the input service in, identified by
+// the shared SERVICE_API_KEY. Starting without it is refused, unless
+// AUTH_DISABLED is set, in which case nil is returned and every request
+// is let through.
+func newServiceAuthenticator(getEnv func(key string) string) (*webserver.Authenticator, error) {
+	disabled, err := env.Bool(getEnv, "AUTH_DISABLED", false)
+	if err != nil {
+		return nil, err
+	}
+	if disabled {
+		return nil, nil
+	}
+
+	serviceKey := getEnv("SERVICE_API_KEY")
+	if serviceKey == "" {
+		return nil, fmt.Errorf("SERVICE_API_KEY is not set: set it, or AUTH_DISABLED=true")
+	}
+
+	store := webserver.NewKeyStore()
+	if err := store.Add("input-service", serviceKey); err != nil {
+		return nil, err
+	}
+	return webserver.NewAuthenticator(store, "orchestrator-service"), nil
+}
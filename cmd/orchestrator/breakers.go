@@ -0,0 +1,74 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/env"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/breaker"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/cep"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/weather"
+)
+
+// breakerRegistry guards every provider with its own circuit breaker and
+// keeps them around for the metrics and the debug endpoint. Setting
+// BREAKER_MIN_REQUESTS to zero disables the breakers.
+type breakerRegistry struct {
+	settings breaker.Settings
+	breakers []*breaker.Breaker
+}
+
+func newBreakerRegistry(logger *slog.Logger, getEnv func(key string) string) (*breakerRegistry, error) {
+	settings := breaker.DefaultSettings()
+
+	var err error
+	if settings.MinRequests, err = env.Int(getEnv, "BREAKER_MIN_REQUESTS", settings.MinRequests); err != nil {
+		return nil, err
+	}
+	if settings.FailureRatio, err = env.Float(getEnv, "BREAKER_FAILURE_RATIO", settings.FailureRatio); err != nil {
+		return nil, err
+	}
+	if settings.Window, err = env.Duration(getEnv, "BREAKER_WINDOW", settings.Window); err != nil {
+		return nil, err
+	}
+	if settings.OpenTimeout, err = env.Duration(getEnv, "BREAKER_OPEN_TIMEOUT", settings.OpenTimeout); err != nil {
+		return nil, err
+	}
+	if settings.HalfOpenRequests, err = env.Int(getEnv, "BREAKER_HALF_OPEN_REQUESTS", settings.HalfOpenRequests); err != nil {
+		return nil, err
+	}
+	settings.OnStateChange = func(name string, from, to breaker.State) {
+		logger.Warn("circuit breaker changed state", "breaker", name, "from", from.String(), "to", to.String())
+	}
+
+	return &breakerRegistry{settings: settings}, nil
+}
+
+func (r *breakerRegistry) enabled() bool {
+	return r.settings.MinRequests > 0
+}
+
+func (r *breakerRegistry) add(name string) *breaker.Breaker {
+	b := breaker.New(name, r.settings)
+	r.breakers = append(r.breakers, b)
+	return b
+}
+
+func (r *breakerRegistry) cep(name string, loader cep.Loader) cep.Loader {
+	if !r.enabled() {
+		return loader
+	}
+	return cep.NewBreakerLoader(loader, r.add("cep."+strings.ToLower(name)))
+}
+
+func (r *breakerRegistry) weather(name string, loader weather.Loader) weather.Loader {
+	if !r.enabled() {
+		return loader
+	}
+	return weather.NewBreakerLoader(loader, r.add("weather."+strings.ToLower(name)))
+}
+
+// list returns the breakers created so far.
+func (r *breakerRegistry) list() []*breaker.Breaker {
+	return r.breakers
+}
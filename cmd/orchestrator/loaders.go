@@ -0,0 +1,261 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/env"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/cep"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/retry"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/weather"
+)
+
+const (
+	defaultCEPProviders          = "awesomeapi,brasilapi"
+	defaultCEPCacheSize          = 10_000
+	defaultCEPCacheTTL           = 24 * time.Hour
+	defaultCEPCacheNegativeTTL   = 10 * time.Minute
+	defaultWeatherProviders      = "weatherapi"
+	defaultWeatherCachePrecision = 2
+	defaultWeatherCacheTTL       = 5 * time.Minute
+	defaultWeatherCacheStaleTTL  = 10 * time.Minute
+)
+
+// newCachedCEPLoader wraps the CEP loader with an in-memory cache sized
+// by CEP_CACHE_SIZE, which disables caching when set to zero.
+func newCachedCEPLoader(tracer trace.Tracer, getEnv func(key string) string, breakers *breakerRegistry) (cep.Loader, error) {
+	loader, err := newCEPLoader(tracer, getEnv, breakers)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := env.Int(getEnv, "CEP_CACHE_SIZE", defaultCEPCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	if size <= 0 {
+		return loader, nil
+	}
+
+	ttl, err := env.Duration(getEnv, "CEP_CACHE_TTL", defaultCEPCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	negativeTTL, err := env.Duration(getEnv, "CEP_CACHE_NEGATIVE_TTL", defaultCEPCacheNegativeTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return cep.NewCachedLoader(loader, size, ttl, negativeTTL), nil
+}
+
+// newCEPLoader builds the CEP loader from the comma separated
+// CEP_PROVIDERS list. By default the providers are tried in the given
+// order; with CEP_STRATEGY=race they are queried concurrently, each one
+// started CEP_HEDGE_DELAY after the previous.
+func newCEPLoader(tracer trace.Tracer, getEnv func(key string) string, breakers *breakerRegistry) (cep.Loader, error) {
+	providers := getEnv("CEP_PROVIDERS")
+	if providers == "" {
+		providers = defaultCEPProviders
+	}
+
+	tlsSettings, err := loadProviderTLS(getEnv)
+	if err != nil {
+		return nil, err
+	}
+	retryPolicy, err := loadProviderRetry(getEnv)
+	if err != nil {
+		return nil, err
+	}
+	opts := append(tlsSettings.cepOptions(), cep.WithRetryPolicy(retryPolicy))
+
+	var loaders []cep.Loader
+	located := false
+	for _, name := range strings.Split(providers, ",") {
+		name = strings.TrimSpace(name)
+		loader, err := newCEPProvider(name, opts)
+		if err != nil {
+			return nil, err
+		}
+		loaders = append(loaders, breakers.cep(name, loader))
+		located = located || !strings.EqualFold(name, "viacep")
+	}
+	// ViaCEP has no coordinates, so it can only confirm a CEP exists
+	// while another provider locates it.
+	if !located {
+		return nil, fmt.Errorf("CEP_PROVIDERS needs a provider returning coordinates: viacep does not")
+	}
+
+	if len(loaders) == 1 {
+		return loaders[0], nil
+	}
+
+	switch strategy := getEnv("CEP_STRATEGY"); strategy {
+	case "", "fallback":
+		return cep.NewFallbackLoader(tracer, loaders...), nil
+	case "race":
+		hedgeDelay, err := env.Duration(getEnv, "CEP_HEDGE_DELAY", 0)
+		if err != nil {
+			return nil, err
+		}
+		return cep.NewRaceLoader(tracer, hedgeDelay, loaders...), nil
+	default:
+		return nil, fmt.Errorf("unknown cep strategy %q", strategy)
+	}
+}
+
+func newCEPProvider(name string, opts []cep.Option) (cep.Loader, error) {
+	switch strings.ToLower(name) {
+	case "awesomeapi":
+		return cep.NewAwesomeAPILoader(opts...)
+	case "brasilapi":
+		return cep.NewBrasilAPILoader(opts...)
+	case "viacep":
+		return cep.NewViaCEPLoader(opts...)
+	default:
+		return nil, fmt.Errorf("unknown cep provider %q", name)
+	}
+}
+
+// newCachedWeatherLoader wraps the weather loader with an in-memory cache
+// keyed by coordinates rounded to WEATHER_CACHE_PRECISION decimal places.
+// Setting WEATHER_CACHE_TTL to zero disables caching.
+func newCachedWeatherLoader(tracer trace.Tracer, getEnv func(key string) string, breakers *breakerRegistry, quotas *quotaRegistry) (weather.Loader, error) {
+	loader, err := newWeatherLoader(tracer, getEnv, breakers, quotas)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err := env.Duration(getEnv, "WEATHER_CACHE_TTL", defaultWeatherCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		return loader, nil
+	}
+
+	staleTTL, err := env.Duration(getEnv, "WEATHER_CACHE_STALE_TTL", defaultWeatherCacheStaleTTL)
+	if err != nil {
+		return nil, err
+	}
+	precision, err := env.Int(getEnv, "WEATHER_CACHE_PRECISION", defaultWeatherCachePrecision)
+	if err != nil {
+		return nil, err
+	}
+
+	return weather.NewCachedLoader(loader, precision, ttl, staleTTL), nil
+}
+
+// newWeatherLoader builds the weather loader from the comma separated
+// WEATHER_PROVIDER list, tried in the given order. WeatherAPI is used by
+// default; "openmeteo" needs no API key.
+func newWeatherLoader(tracer trace.Tracer, getEnv func(key string) string, breakers *breakerRegistry, quotas *quotaRegistry) (weather.Loader, error) {
+	providers := getEnv("WEATHER_PROVIDER")
+	if providers == "" {
+		providers = defaultWeatherProviders
+	}
+
+	tlsSettings, err := loadProviderTLS(getEnv)
+	if err != nil {
+		return nil, err
+	}
+	retryPolicy, err := loadProviderRetry(getEnv)
+	if err != nil {
+		return nil, err
+	}
+	opts := append(tlsSettings.weatherOptions(), weather.WithRetryPolicy(retryPolicy), quotas.option())
+
+	var loaders []weather.Loader
+	for _, name := range strings.Split(providers, ",") {
+		name = strings.TrimSpace(name)
+		loader, err := newWeatherProvider(name, getEnv, opts, quotas)
+		if err != nil {
+			return nil, err
+		}
+		loaders = append(loaders, breakers.weather(name, loader))
+	}
+
+	if len(loaders) == 1 {
+		return loaders[0], nil
+	}
+	return weather.NewFallbackLoader(tracer, loaders...), nil
+}
+
+// newWeatherProvider builds a single provider. WEATHER_APIKEY may hold a
+// comma separated pool of WeatherAPI keys.
+func newWeatherProvider(name string, getEnv func(key string) string, opts []weather.Option, quotas *quotaRegistry) (weather.Loader, error) {
+	switch strings.ToLower(name) {
+	case "weatherapi":
+		loader, err := weather.NewWeatherAPILoader(getEnv("WEATHER_APIKEY"), opts...)
+		if err != nil {
+			return nil, err
+		}
+		return quotas.add(loader), nil
+	case "openmeteo":
+		return weather.NewOpenMeteoLoader(opts...)
+	default:
+		return nil, fmt.Errorf("unknown weather provider %q", name)
+	}
+}
+
+// loadProviderRetry reads how transient provider failures are retried.
+// PROVIDER_RETRY_MAX_ATTEMPTS=1 disables retries.
+func loadProviderRetry(getEnv func(key string) string) (retry.Policy, error) {
+	policy := retry.DefaultPolicy()
+
+	var err error
+	if policy.MaxAttempts, err = env.Int(getEnv, "PROVIDER_RETRY_MAX_ATTEMPTS", policy.MaxAttempts); err != nil {
+		return retry.Policy{}, err
+	}
+	if policy.BaseDelay, err = env.Duration(getEnv, "PROVIDER_RETRY_BASE_DELAY", policy.BaseDelay); err != nil {
+		return retry.Policy{}, err
+	}
+	if policy.MaxDelay, err = env.Duration(getEnv, "PROVIDER_RETRY_MAX_DELAY", policy.MaxDelay); err != nil {
+		return retry.Policy{}, err
+	}
+	return policy, nil
+}
+
+// providerTLS holds how the provider certificates are verified. Only
+// development setups should set PROVIDER_INSECURE_SKIP_VERIFY.
+type providerTLS struct {
+	caBundle string
+	insecure bool
+}
+
+func loadProviderTLS(getEnv func(key string) string) (providerTLS, error) {
+	insecure, err := env.Bool(getEnv, "PROVIDER_INSECURE_SKIP_VERIFY", false)
+	if err != nil {
+		return providerTLS{}, err
+	}
+	return providerTLS{
+		caBundle: getEnv("PROVIDER_CA_BUNDLE"),
+		insecure: insecure,
+	}, nil
+}
+
+func (p providerTLS) cepOptions() []cep.Option {
+	var opts []cep.Option
+	if p.insecure {
+		opts = append(opts, cep.WithTLSConfig(&tls.Config{InsecureSkipVerify: true}))
+	}
+	if p.caBundle != "" {
+		opts = append(opts, cep.WithCABundle(p.caBundle))
+	}
+	return opts
+}
+
+func (p providerTLS) weatherOptions() []weather.Option {
+	var opts []weather.Option
+	if p.insecure {
+		opts = append(opts, weather.WithTLSConfig(&tls.Config{InsecureSkipVerify: true}))
+	}
+	if p.caBundle != "" {
+		opts = append(opts, weather.WithCABundle(p.caBundle))
+	}
+	return opts
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"net/http"
 	"os"
@@ -15,10 +14,21 @@ import (
 
 	"go.opentelemetry.io/otel"
 
+	"github.com/allanmaral/go-expert-otel-challenge/internal/env"
+	"github.com/allanmaral/go-expert-otel-challenge/internal/logging"
 	"github.com/allanmaral/go-expert-otel-challenge/internal/opentelemetry"
 	"github.com/allanmaral/go-expert-otel-challenge/internal/orchestrator"
-	"github.com/allanmaral/go-expert-otel-challenge/pkg/cep"
-	"github.com/allanmaral/go-expert-otel-challenge/pkg/weather"
+)
+
+// The request budget caps every lookup, even when the caller announced a
+// longer one. The server write timeout leaves some room past it to send
+// the 504 answer.
+const (
+	defaultRequestBudget = 10 * time.Second
+	readHeaderTimeout    = 5 * time.Second
+	readTimeout          = 10 * time.Second
+	writeTimeoutMargin   = 5 * time.Second
+	idleTimeout          = 120 * time.Second
 )
 
 func run(
@@ -30,24 +40,82 @@ func run(
 	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	providerShutdown, err := opentelemetry.InitProvider(ctx, "orchestrator-service", getEnv("OTEL_EXPORTER_URL"))
+	otelOpts, err := opentelemetry.OptionsFromEnv(getEnv)
+	if err != nil {
+		return fmt.Errorf("failed to read the OTEL configuration: %w", err)
+	}
+
+	shipLogs := getEnv("OTEL_LOGS_EXPORTER") == "otlp"
+	if shipLogs {
+		otelOpts = append(otelOpts, opentelemetry.WithLogs())
+	}
+
+	telemetry, err := opentelemetry.InitProvider(ctx, "orchestrator-service", getEnv("OTEL_EXPORTER_URL"), otelOpts...)
 	if err != nil {
 		return fmt.Errorf("failed to initialize the OTEL provider: %w", err)
 	}
 
-	logger := log.New(stdout, "ORCHESTRATOR: ", log.LstdFlags)
+	logger := logging.New(stdout, "orchestrator-service", shipLogs)
 	tracer := otel.Tracer("orchestrator-service")
-	cepLoader := cep.NewAwesomeAPILoader()
-	weatherLoader := weather.NewWeatherAPILoader(getEnv("WEATHER_APIKEY"))
+	meter := otel.Meter("orchestrator-service")
+	breakers, err := newBreakerRegistry(logger, getEnv)
+	if err != nil {
+		return err
+	}
+	quotas, err := newQuotaRegistry(getEnv)
+	if err != nil {
+		return err
+	}
+	cepLoader, err := newCachedCEPLoader(tracer, getEnv, breakers)
+	if err != nil {
+		return fmt.Errorf("failed to create the cep loader: %w", err)
+	}
+	weatherLoader, err := newCachedWeatherLoader(tracer, getEnv, breakers, quotas)
+	if err != nil {
+		return fmt.Errorf("failed to create the weather loader: %w", err)
+	}
+
+	if err := registerCacheMetrics(meter, weatherLoader); err != nil {
+		return fmt.Errorf("failed to register the weather cache metrics: %w", err)
+	}
+	if err := registerBreakerMetrics(meter, breakers.list()); err != nil {
+		return fmt.Errorf("failed to register the circuit breaker metrics: %w", err)
+	}
+	if err := registerQuotaMetrics(meter, quotas.list()); err != nil {
+		return fmt.Errorf("failed to register the weather quota metrics: %w", err)
+	}
+
+	warmupTimeout, err := env.Duration(getEnv, "PROVIDER_WARMUP_TIMEOUT", defaultProviderWarmupTimeout)
+	if err != nil {
+		return err
+	}
+	warmupProviders(ctx, logger, warmupTimeout, cepLoader, weatherLoader)
+
+	budget, err := env.Duration(getEnv, "REQUEST_BUDGET", defaultRequestBudget)
+	if err != nil {
+		return err
+	}
+
+	auth, err := newServiceAuthenticator(getEnv)
+	if err != nil {
+		return err
+	}
+	if auth == nil {
+		logger.Warn("authentication disabled: AUTH_DISABLED is set")
+	}
 
-	srv := orchestrator.New(logger, tracer, cepLoader, weatherLoader)
+	srv := orchestrator.New(logger, tracer, meter, telemetry, cepLoader, weatherLoader, breakers.list(), budget, auth)
 	httpServer := &http.Server{
-		Addr:    net.JoinHostPort("0.0.0.0", "8181"),
-		Handler: srv,
+		Addr:              net.JoinHostPort("0.0.0.0", "8181"),
+		Handler:           srv,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      budget + writeTimeoutMargin,
+		IdleTimeout:       idleTimeout,
 	}
 
 	go func() {
-		logger.Printf("listening on %s\n", httpServer.Addr)
+		logger.Info("listening", "addr", httpServer.Addr)
 		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			_, _ = fmt.Fprintf(stderr, "error listening and serving: %s\n", err)
 		}
@@ -62,11 +130,11 @@ func run(
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		logger.Printf("shutting http server down...\n")
+		logger.Info("shutting http server down")
 		if err := httpServer.Shutdown(shutdownCtx); err != nil {
 			_, _ = fmt.Fprintf(stderr, "error shutting http server down: %s\n", err)
 		}
-		if err := providerShutdown(shutdownCtx); err != nil {
+		if err := telemetry.Shutdown(shutdownCtx); err != nil {
 			_, _ = fmt.Fprintf(stderr, "error shutting OTEL provider down: %s\n", err)
 		}
 	}()
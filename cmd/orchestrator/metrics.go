@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/breaker"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/weather"
+)
+
+// registerCacheMetrics exposes the weather cache counters, if the loader
+// is cached at all.
+func registerCacheMetrics(meter metric.Meter, loader weather.Loader) error {
+	cached, ok := loader.(*weather.CachedLoader)
+	if !ok {
+		return nil
+	}
+
+	lookups, err := meter.Int64ObservableCounter(
+		"weather.cache.lookups",
+		metric.WithDescription("Number of weather cache lookups by result."),
+		metric.WithUnit("{lookup}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		stats := cached.Stats()
+		o.ObserveInt64(lookups, stats.Hits, metric.WithAttributes(attribute.String("result", "hit")))
+		o.ObserveInt64(lookups, stats.StaleHits, metric.WithAttributes(attribute.String("result", "stale")))
+		o.ObserveInt64(lookups, stats.Misses, metric.WithAttributes(attribute.String("result", "miss")))
+		return nil
+	}, lookups)
+	return err
+}
+
+// registerBreakerMetrics exposes the state of every provider circuit
+// breaker: 0 closed, 1 half-open and 2 open.
+func registerBreakerMetrics(meter metric.Meter, breakers []*breaker.Breaker) error {
+	if len(breakers) == 0 {
+		return nil
+	}
+
+	state, err := meter.Int64ObservableGauge(
+		"breaker.state",
+		metric.WithDescription("State of the provider circuit breakers: 0 closed, 1 half-open, 2 open."),
+		metric.WithUnit("{state}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		for _, b := range breakers {
+			o.ObserveInt64(state, int64(b.State()), metric.WithAttributes(attribute.String("breaker.name", b.Name())))
+		}
+		return nil
+	}, state)
+	return err
+}
+
+// registerQuotaMetrics exposes how many calls each WeatherAPI key made this
+// month and, when the provider tells, how many it has left.
+func registerQuotaMetrics(meter metric.Meter, loaders []*weather.WeatherAPILoader) error {
+	if len(loaders) == 0 {
+		return nil
+	}
+
+	calls, err := meter.Int64ObservableGauge(
+		"weather.quota.calls",
+		metric.WithDescription("Number of calls made with each WeatherAPI key in the current month."),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return err
+	}
+	remaining, err := meter.Int64ObservableGauge(
+		"weather.quota.remaining",
+		metric.WithDescription("Number of calls each WeatherAPI key has left, as reported by the provider."),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		for _, loader := range loaders {
+			for _, usage := range loader.Quota() {
+				attrs := metric.WithAttributes(attribute.String("weather.quota.key", usage.Key))
+				o.ObserveInt64(calls, usage.Calls, attrs)
+				if usage.Remaining >= 0 {
+					o.ObserveInt64(remaining, usage.Remaining, attrs)
+				}
+			}
+		}
+		return nil
+	}, calls, remaining)
+	return err
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/allanmaral/go-expert-otel-challenge/internal/env"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/weather"
+)
+
+// quotaRegistry applies the WeatherAPI key caps and keeps the loaders
+// around so the usage of their keys can be exported as metrics.
+// WEATHER_API_QUOTA is the number of calls each key may make per month,
+// zero when unknown, and WeatherAPI stops using a key
+// WEATHER_API_QUOTA_RESERVE calls short of it, 1% of the cap by default.
+type quotaRegistry struct {
+	settings weather.QuotaSettings
+	loaders  []*weather.WeatherAPILoader
+}
+
+func newQuotaRegistry(getEnv func(key string) string) (*quotaRegistry, error) {
+	limit, err := env.Int(getEnv, "WEATHER_API_QUOTA", 0)
+	if err != nil {
+		return nil, err
+	}
+	reserve, err := env.Int(getEnv, "WEATHER_API_QUOTA_RESERVE", limit/100)
+	if err != nil {
+		return nil, err
+	}
+
+	return &quotaRegistry{
+		settings: weather.QuotaSettings{Limit: int64(limit), Reserve: int64(reserve)},
+	}, nil
+}
+
+func (r *quotaRegistry) option() weather.Option {
+	return weather.WithQuota(r.settings)
+}
+
+func (r *quotaRegistry) add(loader *weather.WeatherAPILoader) *weather.WeatherAPILoader {
+	r.loaders = append(r.loaders, loader)
+	return loader
+}
+
+// list returns the WeatherAPI loaders created so far.
+func (r *quotaRegistry) list() []*weather.WeatherAPILoader {
+	return r.loaders
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/provider"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/cep"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/weather"
+)
+
+const defaultProviderWarmupTimeout = 5 * time.Second
+
+// warmupProviders opens the provider connections in the background so the
+// first requests do not pay for the handshakes. Failures are only logged,
+// since the loaders dial again on demand.
+func warmupProviders(
+	ctx context.Context,
+	logger *slog.Logger,
+	timeout time.Duration,
+	cepLoader cep.Loader,
+	weatherLoader weather.Loader,
+) {
+	if timeout <= 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		start := time.Now()
+		if err := provider.Warmup(ctx, cepLoader); err != nil {
+			logger.WarnContext(ctx, "could not warm up the cep providers", "error", err)
+		}
+		if err := provider.Warmup(ctx, weatherLoader); err != nil {
+			logger.WarnContext(ctx, "could not warm up the weather providers", "error", err)
+		}
+		logger.InfoContext(ctx, "provider warmup finished", "duration", time.Since(start))
+	}()
+}
@@ -0,0 +1,65 @@
+// Package env parses the settings the services read from environment
+// variables.
+package env
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Duration parses the duration stored in key, returning def when the
+// variable is not set.
+func Duration(getEnv func(key string) string, key string, def time.Duration) (time.Duration, error) {
+	v := getEnv(key)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// Int parses the integer stored in key, returning def when the variable
+// is not set.
+func Int(getEnv func(key string) string, key string, def int) (int, error) {
+	v := getEnv(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// Bool parses the boolean stored in key, returning def when the variable
+// is not set.
+func Bool(getEnv func(key string) string, key string, def bool) (bool, error) {
+	v := getEnv(key)
+	if v == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+// Float parses the number stored in key, returning def when the variable
+// is not set.
+func Float(getEnv func(key string) string, key string, def float64) (float64, error) {
+	v := getEnv(key)
+	if v == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return f, nil
+}
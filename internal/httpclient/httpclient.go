@@ -0,0 +1,161 @@
+// Package httpclient builds the HTTP clients used to reach the CEP and
+// weather providers, shared by the loaders of pkg/cep and pkg/weather.
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/retry"
+)
+
+// The default client keeps more idle connections per provider than
+// net/http does, so bursts of lookups reuse warm TLS connections instead
+// of dialing new ones. Each attempt waits at most
+// defaultResponseHeaderTimeout for the provider to answer and a whole
+// lookup, retries included, never takes longer than defaultClientTimeout,
+// even when the caller set no deadline.
+const (
+	defaultMaxIdleConns          = 100
+	defaultMaxIdleConnsPerHost   = 32
+	defaultIdleConnTimeout       = 90 * time.Second
+	defaultResponseHeaderTimeout = 5 * time.Second
+	defaultClientTimeout         = 15 * time.Second
+)
+
+// Config describes how a loader reaches its provider. Loaders set it
+// through their own options and call Build once they are applied.
+type Config struct {
+	BaseURL string
+	Client  *http.Client
+
+	tlsConfig   *tls.Config
+	retryPolicy *retry.Policy
+	wrap        func(http.RoundTripper) http.RoundTripper
+}
+
+// SetBaseURL overrides the provider base URL.
+func (c *Config) SetBaseURL(baseURL string) error {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("invalid base url: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid base url %q: scheme and host are required", baseURL)
+	}
+	c.BaseURL = strings.TrimSuffix(baseURL, "/")
+	return nil
+}
+
+// SetHTTPClient replaces the default client.
+func (c *Config) SetHTTPClient(client *http.Client) error {
+	if client == nil {
+		return fmt.Errorf("http client must not be nil")
+	}
+	c.Client = client
+	return nil
+}
+
+// SetRetryPolicy replaces retry.DefaultPolicy in the default client.
+func (c *Config) SetRetryPolicy(policy retry.Policy) {
+	c.retryPolicy = &policy
+}
+
+// SetTLSConfig sets the TLS configuration of the default client.
+func (c *Config) SetTLSConfig(tlsConfig *tls.Config) error {
+	if tlsConfig == nil {
+		return fmt.Errorf("tls config must not be nil")
+	}
+	c.tlsConfig = tlsConfig.Clone()
+	return nil
+}
+
+// LoadCABundle trusts the PEM encoded certificates found at path, on top
+// of the system roots.
+func (c *Config) LoadCABundle(path string) error {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read ca bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no certificates found in ca bundle %q", path)
+	}
+
+	if c.tlsConfig == nil {
+		c.tlsConfig = &tls.Config{}
+	}
+	c.tlsConfig.RootCAs = pool
+	return nil
+}
+
+// WrapTransport has wrap applied to the connection level transport of the
+// default client, below the retries, so it sees every attempt.
+func (c *Config) WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	c.wrap = wrap
+}
+
+// Build checks the options are consistent and creates the default client
+// when none was given.
+func (c *Config) Build() error {
+	if c.Client != nil && c.tlsConfig != nil {
+		return fmt.Errorf("tls options can not be combined with a custom http client")
+	}
+	if c.Client != nil && c.retryPolicy != nil {
+		return fmt.Errorf("retry options can not be combined with a custom http client")
+	}
+	if c.Client != nil {
+		return nil
+	}
+
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.MaxIdleConns = defaultMaxIdleConns
+	tr.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	tr.IdleConnTimeout = defaultIdleConnTimeout
+	tr.ResponseHeaderTimeout = defaultResponseHeaderTimeout
+	if c.tlsConfig != nil {
+		tr.TLSClientConfig = c.tlsConfig
+	}
+
+	var base http.RoundTripper = tr
+	if c.wrap != nil {
+		base = c.wrap(base)
+	}
+	policy := retry.DefaultPolicy()
+	if c.retryPolicy != nil {
+		policy = *c.retryPolicy
+	}
+	c.Client = &http.Client{
+		Transport: NewTransport(retry.NewTransport(base, policy)),
+		Timeout:   defaultClientTimeout,
+	}
+	return nil
+}
+
+// Warmup opens a connection to the provider so the first lookup does not
+// pay for the TCP and TLS handshakes. The response itself is discarded.
+func Warmup(ctx context.Context, client *http.Client, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, baseURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
@@ -0,0 +1,46 @@
+package httpclient
+
+import (
+	"net/http"
+	"strconv"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewTransport wraps base so every outbound request gets a client span,
+// named after the method and the host called, and carries the trace
+// context. Like webserver.WithTracing, it adds the stable HTTP attributes
+// otelhttp leaves out.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(clientAttributes{base},
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.URL.Host
+		}),
+	)
+}
+
+type clientAttributes struct {
+	base http.RoundTripper
+}
+
+func (t clientAttributes) RoundTrip(req *http.Request) (*http.Response, error) {
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		semconv.ServerAddress(req.URL.Hostname()),
+		semconv.URLFull(req.URL.String()),
+	}
+	if port, err := strconv.Atoi(req.URL.Port()); err == nil {
+		attrs = append(attrs, semconv.ServerPort(port))
+	}
+	span := trace.SpanFromContext(req.Context())
+	span.SetAttributes(attrs...)
+
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	}
+	return resp, err
+}
@@ -0,0 +1,60 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNewTransport(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	t.Run("NewTransport should record the stable HTTP attributes on the client span", func(t *testing.T) {
+		sut := &http.Client{Transport: NewTransport(http.DefaultTransport)}
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL+"/path", nil)
+
+		resp, err := sut.Do(req)
+		if err != nil {
+			t.Fatalf("expected error to be nil, got '%v' instead", err)
+		}
+		resp.Body.Close()
+
+		spans := recorder.Ended()
+		span := spans[len(spans)-1]
+		if want := "GET " + u.Host; span.Name() != want {
+			t.Errorf("expected span name '%s', got '%s' instead", want, span.Name())
+		}
+
+		attrs := attribute.NewSet(span.Attributes()...)
+		want := []attribute.KeyValue{
+			attribute.String("http.request.method", "GET"),
+			attribute.Int("http.response.status_code", http.StatusTeapot),
+			attribute.String("server.address", u.Hostname()),
+			attribute.Int("server.port", port),
+			attribute.String("url.full", srv.URL+"/path"),
+		}
+		for _, kv := range want {
+			if got, ok := attrs.Value(kv.Key); !ok || got != kv.Value {
+				t.Errorf("expected %s to be '%s', got '%s' instead", kv.Key, kv.Value.Emit(), got.Emit())
+			}
+		}
+	})
+}
@@ -1,25 +1,59 @@
 package input
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"time"
 
-	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/metric"
 
+	"github.com/allanmaral/go-expert-otel-challenge/internal/httpclient"
 	"github.com/allanmaral/go-expert-otel-challenge/internal/webserver"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/retry"
 )
 
+// Orchestrator describes how the input service reaches the orchestrator.
+type Orchestrator struct {
+	URL         string
+	RetryPolicy retry.Policy
+	// ServiceKey is the shared credential the orchestrator expects.
+	ServiceKey string
+}
+
 func New(
-	logger *log.Logger,
-	tracer trace.Tracer,
-	orchestratorURL string,
-) http.Handler {
+	logger *slog.Logger,
+	meter metric.Meter,
+	telemetry webserver.TelemetryStatus,
+	orchestrator Orchestrator,
+	budget time.Duration,
+	auth *webserver.Authenticator,
+	ipLimiter *webserver.RateLimiter,
+	limiter *webserver.RateLimiter,
+) (http.Handler, error) {
+	target, err := url.Parse(orchestrator.URL)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("invalid orchestrator url %q", orchestrator.URL)
+	}
+
+	// Every request is forwarded to the same host, so keep enough idle
+	// connections around to serve bursts without dialing again.
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.MaxIdleConns = 100
+	tr.MaxIdleConnsPerHost = 100
+	transport := httpclient.NewTransport(retry.NewTransport(tr, orchestrator.RetryPolicy))
+	proxy := newOrchestratorProxy(logger, transport, target, orchestrator.ServiceKey)
+
 	mux := http.NewServeMux()
-	addRoutes(mux, logger, tracer, orchestratorURL)
+	addRoutes(mux, logger, proxy, telemetry, auth, ipLimiter, limiter)
 
 	var handler http.Handler = mux
+	handler = webserver.WithBudget(budget, handler)
+	handler = webserver.WithMetrics(meter, handler)
 	handler = webserver.WithLogging(logger, handler)
 	handler = webserver.WithRequestID(handler)
+	handler = webserver.WithTracing("input-service", handler)
 
-	return handler
+	return handler, nil
 }
@@ -0,0 +1,85 @@
+package input
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/webserver"
+)
+
+// forwardedRequestHeaders lists the caller headers passed on to the
+// orchestrator. Anything else, credentials included, stays at the edge.
+// Hop-by-hop headers are always stripped by the proxy.
+var forwardedRequestHeaders = []string{
+	"Accept",
+	"Accept-Language",
+	"Content-Type",
+	"User-Agent",
+}
+
+// forwardedResponseHeaders lists the orchestrator headers passed back to
+// the caller.
+var forwardedResponseHeaders = []string{
+	"Cache-Control",
+	"Content-Language",
+	"Content-Length",
+	"Content-Type",
+	"Retry-After",
+}
+
+// budgetMargin is kept out of the budget announced to the orchestrator,
+// so it times out and answers before the input service gives up on it.
+const budgetMargin = 100 * time.Millisecond
+
+// newOrchestratorProxy forwards requests to the orchestrator on the same
+// path, streaming both bodies. The request ID of the incoming request is
+// propagated, so both services log it, and the trace context is injected
+// by transport. The orchestrator is told how much of the request budget
+// is left. Caller credentials are never forwarded; the orchestrator only
+// sees serviceKey.
+func newOrchestratorProxy(logger *slog.Logger, transport http.RoundTripper, target *url.URL, serviceKey string) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.Out.Host = target.Host
+			pr.Out.Header = allowHeaders(pr.Out.Header, forwardedRequestHeaders)
+			pr.Out.Header.Set(webserver.RequestIDHeader, webserver.GetRequestID(pr.In.Context()))
+			webserver.SetBudget(pr.In.Context(), pr.Out.Header, budgetMargin)
+			if serviceKey != "" {
+				pr.Out.Header.Set("Authorization", "Bearer "+serviceKey)
+			}
+			pr.SetXForwarded()
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			resp.Header = allowHeaders(resp.Header, forwardedResponseHeaders)
+			resp.Header.Set(webserver.RequestIDHeader, webserver.GetRequestID(resp.Request.Context()))
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if errors.Is(err, context.DeadlineExceeded) {
+				logger.WarnContext(r.Context(), "request budget exhausted waiting for the orchestrator service", "error", err)
+				_ = webserver.EncodeProblem(w, r, webserver.ProblemDeadlineExceeded, "the request budget was exhausted waiting for the orchestrator service")
+				return
+			}
+			logger.ErrorContext(r.Context(), "could not reach the orchestrator service", "error", err)
+			_ = webserver.EncodeProblem(w, r, webserver.ProblemOrchestratorUnavailable, "orchestrator service is unavailable, try again later")
+		},
+		Transport: transport,
+		ErrorLog:  slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+}
+
+func allowHeaders(h http.Header, allowed []string) http.Header {
+	filtered := make(http.Header, len(allowed))
+	for _, key := range allowed {
+		if values, ok := h[http.CanonicalHeaderKey(key)]; ok {
+			filtered[http.CanonicalHeaderKey(key)] = values
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,187 @@
+package input
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/webserver"
+)
+
+// standInOrchestrator records the last request it got and answers like
+// the orchestrator, with a few headers that must not reach the caller.
+func standInOrchestrator(t *testing.T, received *http.Header) *url.URL {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*received = r.Header.Clone()
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "1")
+		w.Header().Set("Set-Cookie", "session=internal")
+		w.Header().Set("X-Internal", "orchestrator")
+		w.Header().Set(webserver.RequestIDHeader, "orchestrator-id")
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	target, _ := url.Parse(srv.URL)
+	return target
+}
+
+func TestOrchestratorProxy(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var received http.Header
+	target := standInOrchestrator(t, &received)
+	sut := webserver.WithRequestID(newOrchestratorProxy(logger, http.DefaultTransport, target, "service-key"))
+
+	forward := func(headers map[string]string) *httptest.ResponseRecorder {
+		received = nil
+		r := httptest.NewRequest(http.MethodPost, "/api/weather", strings.NewReader(`{"cep":"25808110"}`))
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		sut.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("Proxy should forward the allowed headers and the body", func(t *testing.T) {
+		w := forward(map[string]string{
+			"Content-Type":    "application/json",
+			"Accept":          "application/json",
+			"Accept-Language": "pt-BR",
+			"User-Agent":      "test",
+		})
+
+		for header, value := range map[string]string{
+			"Content-Type":    "application/json",
+			"Accept":          "application/json",
+			"Accept-Language": "pt-BR",
+			"User-Agent":      "test",
+		} {
+			if got := received.Get(header); got != value {
+				t.Errorf("expected %s to be '%s', got '%s' instead", header, value, got)
+			}
+		}
+
+		if got := w.Body.String(); got != `{"cep":"25808110"}` {
+			t.Errorf("expected the body to be forwarded, got '%s' instead", got)
+		}
+	})
+
+	t.Run("Proxy should not forward headers outside the allow-list", func(t *testing.T) {
+		forward(map[string]string{
+			"Cookie":          "session=caller",
+			"X-Forwarded-For": "10.0.0.1",
+			"X-Internal":      "caller",
+		})
+
+		for _, header := range []string{"Cookie", "X-Internal"} {
+			if got := received.Get(header); got != "" {
+				t.Errorf("expected %s not to be forwarded, got '%s' instead", header, got)
+			}
+		}
+
+		if got := received.Get("X-Forwarded-For"); strings.Contains(got, "10.0.0.1") {
+			t.Errorf("expected the caller X-Forwarded-For to be replaced, got '%s' instead", got)
+		}
+	})
+
+	t.Run("Proxy should strip hop-by-hop headers, even allowed ones", func(t *testing.T) {
+		forward(map[string]string{
+			"Connection":      "Accept-Language",
+			"Accept-Language": "pt-BR",
+			"Keep-Alive":      "timeout=5",
+		})
+
+		for _, header := range []string{"Connection", "Accept-Language", "Keep-Alive"} {
+			if got := received.Get(header); got != "" {
+				t.Errorf("expected %s to be stripped, got '%s' instead", header, got)
+			}
+		}
+	})
+
+	t.Run("Proxy should replace the caller credentials with the service key", func(t *testing.T) {
+		forward(map[string]string{
+			"Authorization":        "Bearer caller-key",
+			webserver.APIKeyHeader: "caller-key",
+		})
+
+		if got := received.Get("Authorization"); got != "Bearer service-key" {
+			t.Errorf("expected the service key, got '%s' instead", got)
+		}
+
+		if got := received.Get(webserver.APIKeyHeader); got != "" {
+			t.Errorf("expected %s not to be forwarded, got '%s' instead", webserver.APIKeyHeader, got)
+		}
+	})
+
+	t.Run("Proxy should propagate the request ID both ways", func(t *testing.T) {
+		w := forward(map[string]string{webserver.RequestIDHeader: "caller-id"})
+
+		if got := received.Get(webserver.RequestIDHeader); got != "caller-id" {
+			t.Errorf("expected the orchestrator to get 'caller-id', got '%s' instead", got)
+		}
+
+		if got := w.Header().Get(webserver.RequestIDHeader); got != "caller-id" {
+			t.Errorf("expected the caller to get 'caller-id' back, got '%s' instead", got)
+		}
+	})
+
+	t.Run("Proxy should pass back only the allowed response headers", func(t *testing.T) {
+		w := forward(nil)
+
+		if got := w.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("expected Content-Type to be kept, got '%s' instead", got)
+		}
+
+		if got := w.Header().Get("Retry-After"); got != "1" {
+			t.Errorf("expected Retry-After to be kept, got '%s' instead", got)
+		}
+
+		for _, header := range []string{"Set-Cookie", "X-Internal"} {
+			if got := w.Header().Get(header); got != "" {
+				t.Errorf("expected %s not to be passed back, got '%s' instead", header, got)
+			}
+		}
+	})
+
+	t.Run("Proxy should announce the budget left to the orchestrator", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		r := httptest.NewRequest(http.MethodPost, "/api/weather", strings.NewReader(`{}`)).WithContext(ctx)
+
+		sut.ServeHTTP(httptest.NewRecorder(), r)
+
+		got, err := strconv.Atoi(received.Get(webserver.BudgetHeader))
+		if err != nil || got <= 0 || got > 900 {
+			t.Errorf("expected a budget of at most 900ms, got '%s' instead", received.Get(webserver.BudgetHeader))
+		}
+	})
+
+	t.Run("Proxy should answer a problem when the orchestrator is unreachable", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+		down, _ := url.Parse(srv.URL)
+		sut := newOrchestratorProxy(logger, http.DefaultTransport, down, "service-key")
+		w := httptest.NewRecorder()
+
+		sut.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/weather", strings.NewReader(`{}`)))
+
+		if w.Code != http.StatusBadGateway {
+			t.Errorf("expected status %d, got %d instead", http.StatusBadGateway, w.Code)
+		}
+
+		if got := w.Header().Get("Content-Type"); got != webserver.ProblemContentType {
+			t.Errorf("expected a problem response, got '%s' instead", got)
+		}
+	})
+}
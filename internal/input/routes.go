@@ -3,96 +3,81 @@ package input
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/trace"
-
 	"github.com/allanmaral/go-expert-otel-challenge/internal/webserver"
 	"github.com/allanmaral/go-expert-otel-challenge/pkg/cep"
 )
 
 func addRoutes(
 	mux *http.ServeMux,
-	logger *log.Logger,
-	tracer trace.Tracer,
-	orchestratorURL string,
+	logger *slog.Logger,
+	proxy http.Handler,
+	telemetry webserver.TelemetryStatus,
+	auth *webserver.Authenticator,
+	ipLimiter *webserver.RateLimiter,
+	limiter *webserver.RateLimiter,
 ) {
-	mux.Handle("POST /api/weather", handleGetTemperature(logger, tracer, orchestratorURL))
-	mux.Handle("GET /ready", handleReady())
+	// Requests are limited per IP before the API key is checked, so keys
+	// can not be guessed at will, and per client once it is known.
+	var weather http.Handler = handleGetTemperature(logger, proxy)
+	weather = limiter.Limit("POST /api/weather", weather)
+	weather = auth.Require("weather", weather)
+	weather = ipLimiter.Limit("POST /api/weather", weather)
+	mux.Handle("POST /api/weather", webserver.Route("POST /api/weather", weather))
+	mux.Handle("GET /ready", webserver.Route("GET /ready", limiter.Limit("GET /ready", handleReady(telemetry))))
 }
 
+// maxRequestBodySize bounds the body read while validating the CEP.
+const maxRequestBodySize = 1 << 20
+
 func handleGetTemperature(
-	logger *log.Logger,
-	tracer trace.Tracer,
-	orchestratorURL string,
+	logger *slog.Logger,
+	proxy http.Handler,
 ) http.Handler {
 	type request struct {
 		CEP string `json:"cep"`
 	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		carrier := propagation.HeaderCarrier(r.Header)
 		ctx := r.Context()
-		ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
-		ctx, span := tracer.Start(ctx, "/api/weather")
-		defer span.End()
 
-		reqBody, err := io.ReadAll(r.Body)
+		// The body is small and bounded, so it is kept in memory to be
+		// forwarded as sent and replayed when the call is retried.
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
 		if err != nil {
-			_ = webserver.Encode(w, r, http.StatusBadRequest, webserver.ErrorResponse{Message: "invalid input format"})
+			_ = webserver.EncodeProblem(w, r, webserver.ProblemInvalidInput, "invalid input format")
+			logger.WarnContext(ctx, "could not read the request body", "error", err)
 			return
 		}
 
 		var input request
-		if err := json.NewDecoder(bytes.NewBuffer(reqBody)).Decode(&input); err != nil {
-			_ = webserver.Encode(w, r, http.StatusBadRequest, webserver.ErrorResponse{Message: "invalid input format"})
-			logger.Printf("%s\n", err)
+		if err := json.Unmarshal(body, &input); err != nil {
+			_ = webserver.EncodeProblem(w, r, webserver.ProblemInvalidInput, "invalid input format")
+			logger.WarnContext(ctx, "could not decode the request body", "error", err)
 			return
 		}
 
 		if valid := cep.Valid(input.CEP); !valid {
-			_ = webserver.Encode(w, r, http.StatusUnprocessableEntity, webserver.ErrorResponse{Message: "invalid zipcode"})
+			_ = webserver.EncodeProblem(w, r, webserver.ProblemInvalidZipcode, "invalid zipcode")
 			return
 		}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/api/weather", orchestratorURL), bytes.NewBuffer(reqBody))
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			logger.Printf("could not create request to the orchestrator service %s\n", err)
-			return
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
 		}
-
-		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadGateway)
-			logger.Printf("could not reach the orchestrator service %s\n", err)
-			return
-		}
-		defer resp.Body.Close()
-
-		bodyBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			logger.Printf("could not read the orchestrator service response %s\n", err)
-			return
-		}
-
-		w.WriteHeader(resp.StatusCode)
-		w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
-		w.Write(bodyBytes)
+		r.ContentLength = int64(len(body))
+		proxy.ServeHTTP(w, r)
 	})
 }
 
-func handleReady() http.Handler {
+func handleReady(telemetry webserver.TelemetryStatus) http.Handler {
 	return http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
+			_ = webserver.Encode(w, r, http.StatusOK, webserver.NewReadyResponse(telemetry))
 		},
 	)
 }
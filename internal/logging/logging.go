@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/webserver"
+)
+
+// New returns a logger writing JSON lines to w. Every record logged with
+// a context carries the trace_id, span_id, request_id and client found in
+// it.
+//
+// When shipOTLP is set, records are also handed to the global OpenTelemetry
+// logger provider, which InitProvider configures to export them.
+func New(w io.Writer, serviceName string, shipOTLP bool) *slog.Logger {
+	var handler slog.Handler = slog.NewJSONHandler(w, nil)
+	if shipOTLP {
+		handler = &fanoutHandler{handlers: []slog.Handler{
+			handler,
+			otelslog.NewHandler(serviceName),
+		}}
+	}
+	handler = &contextHandler{Handler: handler}
+
+	return slog.New(handler).With(slog.String("service", serviceName))
+}
+
+// contextHandler adds the request correlation attributes to each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	if requestID := webserver.GetRequestID(ctx); requestID != "" {
+		r.AddAttrs(slog.String("request_id", requestID))
+	}
+	if identity, ok := webserver.GetIdentity(ctx); ok {
+		r.AddAttrs(slog.String("client", identity.Client))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// fanoutHandler hands each record to every handler that accepts it.
+type fanoutHandler struct {
+	handlers []slog.Handler
+}
+
+func (h *fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, r.Level) {
+			errs = append(errs, handler.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return &fanoutHandler{handlers: handlers}
+}
+
+func (h *fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return &fanoutHandler{handlers: handlers}
+}
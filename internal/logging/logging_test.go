@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/webserver"
+)
+
+// recordingProcessor keeps what the OTLP handler hands to the logger
+// provider instead of exporting it.
+type recordingProcessor struct {
+	mu      sync.Mutex
+	records []emitted
+}
+
+type emitted struct {
+	body      string
+	traceID   trace.TraceID
+	requestID string
+}
+
+func (p *recordingProcessor) OnEmit(ctx context.Context, r *sdklog.Record) error {
+	e := emitted{body: r.Body().AsString(), traceID: r.TraceID()}
+	r.WalkAttributes(func(kv log.KeyValue) bool {
+		if kv.Key == "request_id" {
+			e.requestID = kv.Value.AsString()
+		}
+		return true
+	})
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.records = append(p.records, e)
+	return nil
+}
+
+func (p *recordingProcessor) Shutdown(ctx context.Context) error   { return nil }
+func (p *recordingProcessor) ForceFlush(ctx context.Context) error { return nil }
+
+func (p *recordingProcessor) recorded() []emitted {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]emitted(nil), p.records...)
+}
+
+func TestNew(t *testing.T) {
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+	ctx = context.WithValue(ctx, webserver.RequestIDKey, "req-123")
+
+	t.Run("New should add the trace and request ids to the JSON lines", func(t *testing.T) {
+		var out bytes.Buffer
+		sut := New(&out, "test-service", false)
+
+		sut.InfoContext(ctx, "hello")
+
+		var line map[string]any
+		if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+			t.Fatalf("expected a JSON line, got '%v' instead", err)
+		}
+
+		expected := map[string]string{
+			"msg":        "hello",
+			"service":    "test-service",
+			"trace_id":   spanContext.TraceID().String(),
+			"span_id":    spanContext.SpanID().String(),
+			"request_id": "req-123",
+		}
+		for key, value := range expected {
+			if line[key] != value {
+				t.Errorf("expected %s to be '%s', got '%v' instead", key, value, line[key])
+			}
+		}
+	})
+
+	t.Run("New should also hand the records to the OTLP handler when shipping them", func(t *testing.T) {
+		processor := &recordingProcessor{}
+		previous := global.GetLoggerProvider()
+		global.SetLoggerProvider(sdklog.NewLoggerProvider(sdklog.WithProcessor(processor)))
+		t.Cleanup(func() { global.SetLoggerProvider(previous) })
+
+		var out bytes.Buffer
+		sut := New(&out, "test-service", true)
+
+		sut.InfoContext(ctx, "hello")
+
+		if out.Len() == 0 {
+			t.Errorf("expected the JSON line to still be written")
+		}
+
+		records := processor.recorded()
+		if len(records) != 1 {
+			t.Fatalf("expected 1 OTLP record, got %d instead", len(records))
+		}
+		if records[0].body != "hello" {
+			t.Errorf("expected body 'hello', got '%s' instead", records[0].body)
+		}
+		if records[0].traceID != spanContext.TraceID() {
+			t.Errorf("expected trace id '%s', got '%s' instead", spanContext.TraceID(), records[0].traceID)
+		}
+		if records[0].requestID != "req-123" {
+			t.Errorf("expected request_id 'req-123', got '%s' instead", records[0].requestID)
+		}
+	})
+}
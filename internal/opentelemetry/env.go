@@ -0,0 +1,22 @@
+package opentelemetry
+
+// OptionsFromEnv reads the sampling, exporters and propagators settings
+// from the standard OTEL_* environment variables.
+func OptionsFromEnv(getEnv func(key string) string) ([]Option, error) {
+	opts, err := samplingFromEnv(getEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	exporters, err := exportersFromEnv(getEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	propagators, err := propagatorsFromEnv(getEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(opts, exporters, propagators), nil
+}
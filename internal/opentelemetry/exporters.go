@@ -0,0 +1,272 @@
+package opentelemetry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	tracesExporterOTLP    = "otlp"
+	tracesExporterConsole = "console"
+	tracesExporterFile    = "file"
+	tracesExporterNone    = "none"
+
+	protocolGRPC         = "grpc"
+	protocolHTTPProtobuf = "http/protobuf"
+
+	defaultTracesFile = "traces.jsonl"
+
+	// defaultHTTPTracesPort and defaultHTTPTracesPath locate the OTLP/HTTP
+	// receiver of the collector when no traces endpoint is set.
+	defaultHTTPTracesPort = "4318"
+	defaultHTTPTracesPath = "/v1/traces"
+)
+
+// exporterConfig selects where spans are sent. The OTLP settings also
+// apply to the collector connection shared with metrics and logs.
+type exporterConfig struct {
+	traces         string
+	protocol       string
+	tracesEndpoint string
+	tracesFile     string
+	headers        map[string]string
+	insecure       bool
+	certificate    string
+}
+
+func defaultExporterConfig() exporterConfig {
+	return exporterConfig{
+		traces:   tracesExporterOTLP,
+		protocol: protocolGRPC,
+		insecure: true,
+	}
+}
+
+// exportersFromEnv reads the trace exporter from OTEL_TRACES_EXPORTER
+// (otlp, console, file or none) and the OTLP settings from
+// OTEL_EXPORTER_OTLP_PROTOCOL, OTEL_EXPORTER_OTLP_TRACES_ENDPOINT,
+// OTEL_EXPORTER_OTLP_HEADERS, OTEL_EXPORTER_OTLP_INSECURE and
+// OTEL_EXPORTER_OTLP_CERTIFICATE. The traces endpoint is a full URL, as in
+// the OTLP specification, and its scheme decides whether TLS is used. The
+// file exporter writes JSON lines to OTEL_EXPORTER_FILE_PATH.
+func exportersFromEnv(getEnv func(key string) string) (Option, error) {
+	cfg := defaultExporterConfig()
+
+	if v := strings.ToLower(getEnv("OTEL_TRACES_EXPORTER")); v != "" {
+		switch v {
+		case tracesExporterOTLP, tracesExporterConsole, tracesExporterFile, tracesExporterNone:
+			cfg.traces = v
+		default:
+			return nil, fmt.Errorf("unknown OTEL_TRACES_EXPORTER %q", v)
+		}
+	}
+
+	if v := strings.ToLower(getEnv("OTEL_EXPORTER_OTLP_PROTOCOL")); v != "" {
+		switch v {
+		case protocolGRPC, protocolHTTPProtobuf:
+			cfg.protocol = v
+		default:
+			return nil, fmt.Errorf("unsupported OTEL_EXPORTER_OTLP_PROTOCOL %q", v)
+		}
+	}
+
+	if v := getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); v != "" {
+		u, err := url.Parse(v)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_TRACES_ENDPOINT %q: expected an http or https URL", v)
+		}
+		cfg.tracesEndpoint = v
+	}
+	cfg.tracesFile = getEnv("OTEL_EXPORTER_FILE_PATH")
+	cfg.certificate = getEnv("OTEL_EXPORTER_OTLP_CERTIFICATE")
+
+	if v := getEnv("OTEL_EXPORTER_OTLP_INSECURE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_INSECURE: %w", err)
+		}
+		cfg.insecure = b
+	}
+
+	headers, err := parseHeaders(getEnv("OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.headers = headers
+
+	return func(c *config) {
+		c.exporters = cfg
+	}, nil
+}
+
+// parseHeaders parses the W3C baggage like "key1=value1,key2=value2"
+// format, with URL encoded values.
+func parseHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS entry %q", pair)
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS value for %q: %w", k, err)
+		}
+		headers[strings.TrimSpace(k)] = value
+	}
+	return headers, nil
+}
+
+func (c exporterConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if c.certificate == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(c.certificate)
+	if err != nil {
+		return nil, fmt.Errorf("read OTLP certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %q", c.certificate)
+	}
+	tlsConfig.RootCAs = pool
+	return tlsConfig, nil
+}
+
+// dial creates the lazy gRPC connection to the collector.
+func (c exporterConfig) dial(collectorURL string) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if !c.insecure {
+		tlsConfig, err := c.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	conn, err := grpc.NewClient(collectorURL, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	conn.Connect()
+	return conn, nil
+}
+
+// tracesInsecure reports whether spans are sent without TLS.
+func (c exporterConfig) tracesInsecure() bool {
+	if c.tracesEndpoint != "" {
+		return !strings.HasPrefix(c.tracesEndpoint, "https:")
+	}
+	return c.insecure
+}
+
+// httpTracesEndpoint returns where spans are sent over HTTP: the traces
+// endpoint when set, or else the OTLP/HTTP receiver on the host of the
+// gRPC collector address used for metrics and logs.
+func (c exporterConfig) httpTracesEndpoint(collectorURL string) string {
+	if c.tracesEndpoint != "" {
+		return c.tracesEndpoint
+	}
+	host, _, err := net.SplitHostPort(collectorURL)
+	if err != nil {
+		host = collectorURL
+	}
+	scheme := "https"
+	if c.insecure {
+		scheme = "http"
+	}
+	return (&url.URL{Scheme: scheme, Host: net.JoinHostPort(host, defaultHTTPTracesPort), Path: defaultHTTPTracesPath}).String()
+}
+
+// newTraceExporter builds the span exporter selected by the config. The
+// returned close func releases resources the exporter does not own, and
+// a nil exporter means spans are not exported at all.
+func (c exporterConfig) newTraceExporter(ctx context.Context, conn *grpc.ClientConn, collectorURL string) (sdktrace.SpanExporter, func() error, error) {
+	noop := func() error { return nil }
+
+	switch c.traces {
+	case tracesExporterNone:
+		return nil, noop, nil
+
+	case tracesExporterConsole:
+		exp, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		return exp, noop, err
+
+	case tracesExporterFile:
+		path := c.tracesFile
+		if path == "" {
+			path = defaultTracesFile
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, noop, fmt.Errorf("open traces file: %w", err)
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, noop, err
+		}
+		return exp, f.Close, nil
+	}
+
+	if c.protocol == protocolHTTPProtobuf {
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithHeaders(c.headers),
+			otlptracehttp.WithRetry(otlptracehttp.RetryConfig(exportRetry)),
+		}
+		opts = append(opts, otlptracehttp.WithEndpointURL(c.httpTracesEndpoint(collectorURL)))
+		if c.tracesInsecure() {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		} else {
+			tlsConfig, err := c.tlsConfig()
+			if err != nil {
+				return nil, noop, err
+			}
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsConfig))
+		}
+		exp, err := otlptracehttp.New(ctx, opts...)
+		return exp, noop, err
+	}
+
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithHeaders(c.headers),
+		otlptracegrpc.WithRetry(exportRetry),
+	}
+	if c.tracesEndpoint == "" {
+		opts = append(opts, otlptracegrpc.WithGRPCConn(conn))
+	} else {
+		opts = append(opts, otlptracegrpc.WithEndpointURL(c.tracesEndpoint))
+		if c.tracesInsecure() {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		} else {
+			tlsConfig, err := c.tlsConfig()
+			if err != nil {
+				return nil, noop, err
+			}
+			opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+		}
+	}
+	exp, err := otlptracegrpc.New(ctx, opts...)
+	return exp, noop, err
+}
@@ -0,0 +1,117 @@
+package opentelemetry
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestExportersFromEnv(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantEndpoint string
+		wantInsecure bool
+		wantErr      bool
+	}{
+		{name: "exportersFromEnv should default to the insecure collector connection", wantInsecure: true},
+		{name: "exportersFromEnv should read the traces endpoint URL", env: map[string]string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "http://collector:4318/v1/traces"}, wantEndpoint: "http://collector:4318/v1/traces", wantInsecure: true},
+		{name: "exportersFromEnv should use TLS for an https traces endpoint", env: map[string]string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "https://collector:4318/v1/traces"}, wantEndpoint: "https://collector:4318/v1/traces"},
+		{name: "exportersFromEnv should let the endpoint scheme override OTEL_EXPORTER_OTLP_INSECURE", env: map[string]string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "http://collector:4318/v1/traces", "OTEL_EXPORTER_OTLP_INSECURE": "false"}, wantEndpoint: "http://collector:4318/v1/traces", wantInsecure: true},
+		{name: "exportersFromEnv should honor OTEL_EXPORTER_OTLP_INSECURE without an endpoint", env: map[string]string{"OTEL_EXPORTER_OTLP_INSECURE": "false"}},
+		{name: "exportersFromEnv should reject a traces endpoint without a scheme", env: map[string]string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "collector:4318"}, wantErr: true},
+		{name: "exportersFromEnv should reject a traces endpoint with another scheme", env: map[string]string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "grpc://collector:4317"}, wantErr: true},
+		{name: "exportersFromEnv should reject an unknown protocol", env: map[string]string{"OTEL_EXPORTER_OTLP_PROTOCOL": "http/json"}, wantErr: true},
+		{name: "exportersFromEnv should reject an unknown exporter", env: map[string]string{"OTEL_TRACES_EXPORTER": "zipkin"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt, err := exportersFromEnv(func(key string) string { return tt.env[key] })
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got nil instead")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil, got '%v' instead", err)
+			}
+
+			var c config
+			opt(&c)
+
+			if c.exporters.tracesEndpoint != tt.wantEndpoint {
+				t.Errorf("expected endpoint '%s', got '%s' instead", tt.wantEndpoint, c.exporters.tracesEndpoint)
+			}
+
+			if got := c.exporters.tracesInsecure(); got != tt.wantInsecure {
+				t.Errorf("expected insecure to be %t, got %t instead", tt.wantInsecure, got)
+			}
+		})
+	}
+}
+
+func TestExporterConfig_HTTPTracesEndpoint(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    exporterConfig
+		collector string
+		want      string
+	}{
+		{name: "httpTracesEndpoint should use the traces endpoint when set", config: exporterConfig{tracesEndpoint: "https://traces.example.com/v1/traces"}, collector: "otel-collector:4317", want: "https://traces.example.com/v1/traces"},
+		{name: "httpTracesEndpoint should default to the HTTP port of the collector host", config: exporterConfig{insecure: true}, collector: "otel-collector:4317", want: "http://otel-collector:4318/v1/traces"},
+		{name: "httpTracesEndpoint should use TLS when the collector connection does", config: exporterConfig{}, collector: "otel-collector:4317", want: "https://otel-collector:4318/v1/traces"},
+		{name: "httpTracesEndpoint should accept a collector address without a port", config: exporterConfig{insecure: true}, collector: "otel-collector", want: "http://otel-collector:4318/v1/traces"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.config.httpTracesEndpoint(tt.collector)
+
+			if got != tt.want {
+				t.Errorf("expected '%s', got '%s' instead", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "parseHeaders should accept an empty value", value: "", want: map[string]string{}},
+		{name: "parseHeaders should read several headers", value: "api-key=secret,tenant=acme", want: map[string]string{"api-key": "secret", "tenant": "acme"}},
+		{name: "parseHeaders should decode the values", value: "authorization=Bearer%20token", want: map[string]string{"authorization": "Bearer token"}},
+		{name: "parseHeaders should trim spaces and skip empty entries", value: " tenant = acme ,, ", want: map[string]string{"tenant": "acme"}},
+		{name: "parseHeaders should keep equal signs in the value", value: "token=a=b", want: map[string]string{"token": "a=b"}},
+		{name: "parseHeaders should require a value separator", value: "tenant", wantErr: true},
+		{name: "parseHeaders should require a key", value: "=acme", wantErr: true},
+		{name: "parseHeaders should reject malformed escapes", value: "tenant=%zz", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHeaders(tt.value)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %v instead", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil, got '%v' instead", err)
+			}
+
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v instead", tt.want, got)
+			}
+		})
+	}
+}
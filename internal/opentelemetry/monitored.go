@@ -0,0 +1,72 @@
+package opentelemetry
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Dropped spans are counted with the reason they were lost.
+var (
+	droppedExportFailed = metric.WithAttributes(attribute.String("reason", "export_failed"))
+	droppedQueueFull    = metric.WithAttributes(attribute.String("reason", "queue_full"))
+)
+
+// monitoredExporter counts the spans its exporter delivers or drops and
+// remembers whether the last export failed. Spans lost before reaching
+// the exporter are reported through queueFull.
+type monitoredExporter struct {
+	sdktrace.SpanExporter
+	exported   metric.Int64Counter
+	dropped    metric.Int64Counter
+	lastFailed atomic.Bool
+}
+
+func newMonitoredExporter(exporter sdktrace.SpanExporter, meter metric.Meter) (*monitoredExporter, error) {
+	exported, err := meter.Int64Counter(
+		"telemetry.spans.exported",
+		metric.WithDescription("Number of spans delivered to the collector."),
+		metric.WithUnit("{span}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	dropped, err := meter.Int64Counter(
+		"telemetry.spans.dropped",
+		metric.WithDescription("Number of spans dropped because they could not be exported or queued."),
+		metric.WithUnit("{span}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &monitoredExporter{
+		SpanExporter: exporter,
+		exported:     exported,
+		dropped:      dropped,
+	}, nil
+}
+
+func (e *monitoredExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	e.lastFailed.Store(err != nil)
+	if err != nil {
+		e.dropped.Add(context.Background(), int64(len(spans)), droppedExportFailed)
+		return err
+	}
+	e.exported.Add(context.Background(), int64(len(spans)))
+	return nil
+}
+
+// queueFull counts a span dropped because the export queue was full.
+func (e *monitoredExporter) queueFull() {
+	e.dropped.Add(context.Background(), 1, droppedQueueFull)
+}
+
+func (e *monitoredExporter) failing() bool {
+	return e.lastFailed.Load()
+}
@@ -0,0 +1,53 @@
+package opentelemetry
+
+import (
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const defaultPropagators = "tracecontext,baggage"
+
+// WithPropagator sets the global text map propagator.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagator = propagator
+	}
+}
+
+// propagatorsFromEnv reads the comma separated OTEL_PROPAGATORS list:
+// tracecontext, baggage, b3 (single header), b3multi, jaeger or none.
+// Every listed format is extracted from incoming requests and injected
+// into outgoing ones.
+func propagatorsFromEnv(getEnv func(key string) string) (Option, error) {
+	names := getEnv("OTEL_PROPAGATORS")
+	if names == "" {
+		names = defaultPropagators
+	}
+
+	var propagators []propagation.TextMapPropagator
+	for _, name := range strings.Split(names, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "tracecontext":
+			propagators = append(propagators, propagation.TraceContext{})
+		case "baggage":
+			propagators = append(propagators, propagation.Baggage{})
+		case "b3":
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3SingleHeader)))
+		case "b3multi":
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)))
+		case "jaeger":
+			propagators = append(propagators, jaeger.Jaeger{})
+		case "none":
+			return WithPropagator(propagation.NewCompositeTextMapPropagator()), nil
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown OTEL_PROPAGATORS entry %q", name)
+		}
+	}
+
+	return WithPropagator(propagation.NewCompositeTextMapPropagator(propagators...)), nil
+}
@@ -0,0 +1,67 @@
+package opentelemetry
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPropagatorsFromEnv(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:     trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "propagatorsFromEnv should default to W3C trace context", want: []string{"traceparent"}},
+		{name: "propagatorsFromEnv should inject B3 single header", value: "b3", want: []string{"b3"}},
+		{name: "propagatorsFromEnv should inject B3 multiple headers", value: "b3multi", want: []string{"x-b3-sampled", "x-b3-spanid", "x-b3-traceid"}},
+		{name: "propagatorsFromEnv should inject Jaeger headers", value: "jaeger", want: []string{"uber-trace-id"}},
+		{name: "propagatorsFromEnv should combine every listed format", value: "tracecontext, B3,", want: []string{"b3", "traceparent"}},
+		{name: "propagatorsFromEnv should inject nothing with none", value: "tracecontext,none"},
+		{name: "propagatorsFromEnv should reject unknown formats", value: "tracecontext,xray", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt, err := propagatorsFromEnv(func(key string) string {
+				if key == "OTEL_PROPAGATORS" {
+					return tt.value
+				}
+				return ""
+			})
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got nil instead")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil, got '%v' instead", err)
+			}
+
+			var c config
+			opt(&c)
+			carrier := propagation.MapCarrier{}
+			c.propagator.Inject(ctx, carrier)
+
+			got := carrier.Keys()
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected headers %v, got %v instead", tt.want, got)
+			}
+		})
+	}
+}
@@ -2,21 +2,82 @@ package opentelemetry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/connectivity"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/log/global"
 	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 )
 
-func InitProvider(ctx context.Context, serviceName, collectorURL string) (func(context.Context) error, error) {
+// exportRetry bounds how long a batch is retried while the collector is
+// unreachable, so the export queues keep draining instead of piling up.
+var exportRetry = otlptracegrpc.RetryConfig{
+	Enabled:         true,
+	InitialInterval: time.Second,
+	MaxInterval:     2 * time.Second,
+	MaxElapsedTime:  5 * time.Second,
+}
+
+type config struct {
+	exporters      exporterConfig
+	logs           bool
+	sampler        sdktrace.Sampler
+	keepErrors     bool
+	keepSlowerThan time.Duration
+	propagator     propagation.TextMapPropagator
+}
+
+// Option configures the telemetry pipelines set up by InitProvider.
+type Option func(*config)
+
+// WithLogs also exports log records through OTLP, using the global
+// logger provider.
+func WithLogs() Option {
+	return func(c *config) {
+		c.logs = true
+	}
+}
+
+// Provider holds the telemetry pipelines installed by InitProvider.
+type Provider struct {
+	conn          *grpc.ClientConn
+	spans         *monitoredExporter
+	shutdownFuncs []func(context.Context) error
+}
+
+// InitProvider installs the global tracer, meter and, optionally, logger
+// providers exporting to the collector at collectorURL.
+//
+// The collector connection is established in the background and retried
+// for as long as the service runs, so the service starts even when the
+// collector is down. Spans that can not be exported in the meantime are
+// dropped and counted.
+func InitProvider(ctx context.Context, serviceName, collectorURL string, opts ...Option) (*Provider, error) {
+	cfg := config{
+		exporters: defaultExporterConfig(),
+		sampler:   sdktrace.ParentBased(sdktrace.AlwaysSample()),
+		propagator: propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	res, err := resource.New(
 		ctx,
 		resource.WithFromEnv(),
@@ -30,32 +91,113 @@ func InitProvider(ctx context.Context, serviceName, collectorURL string) (func(c
 		return nil, fmt.Errorf("failed to create resource: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
-	defer cancel()
+	conn, err := cfg.exporters.dial(collectorURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC connection to collector: %w", err)
+	}
+
+	p := &Provider{conn: conn}
 
-	conn, err := grpc.DialContext(
+	metricExporter, err := otlpmetricgrpc.New(
 		ctx,
-		collectorURL,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock(),
+		otlpmetricgrpc.WithGRPCConn(conn),
+		otlpmetricgrpc.WithHeaders(cfg.exporters.headers),
+		otlpmetricgrpc.WithRetry(otlpmetricgrpc.RetryConfig(exportRetry)),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create gRPC connection to collector: %w", err)
+		return nil, fmt.Errorf("failed to create metric exporter: %w", err)
 	}
 
-	traceExporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithGRPCConn(conn))
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+	)
+	otel.SetMeterProvider(meterProvider)
+
+	traceExporter, closeTraceExporter, err := cfg.exporters.newTraceExporter(ctx, conn, collectorURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
 	}
 
-	bsp := sdktrace.NewBatchSpanProcessor(traceExporter)
-	traceProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	sampler := cfg.sampler
+	var spanProcessor sdktrace.SpanProcessor
+	if traceExporter != nil {
+		p.spans, err = newMonitoredExporter(traceExporter, meterProvider.Meter("github.com/allanmaral/go-expert-otel-challenge/internal/opentelemetry"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create trace exporter metrics: %w", err)
+		}
+		spanProcessor = newQueuedSpanProcessor(
+			sdktrace.NewBatchSpanProcessor(p.spans, sdktrace.WithBlocking()),
+			defaultSpanQueueSize,
+			p.spans.queueFull,
+		)
+	}
+	if spanProcessor != nil && (cfg.keepErrors || cfg.keepSlowerThan > 0) {
+		sampler = recordDroppedSampler{base: sampler}
+		spanProcessor = &keptSpanProcessor{
+			next:       spanProcessor,
+			errors:     cfg.keepErrors,
+			slowerThan: cfg.keepSlowerThan,
+		}
+	}
+
+	traceOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sampler),
 		sdktrace.WithResource(res),
-		sdktrace.WithSpanProcessor(bsp),
-	)
+	}
+	if spanProcessor != nil {
+		traceOpts = append(traceOpts, sdktrace.WithSpanProcessor(spanProcessor))
+	}
+	traceProvider := sdktrace.NewTracerProvider(traceOpts...)
 	otel.SetTracerProvider(traceProvider)
-	otel.SetTextMapPropagator(propagation.TraceContext{})
+	otel.SetTextMapPropagator(cfg.propagator)
+
+	// Traces are flushed first, so their export metrics are still recorded.
+	p.shutdownFuncs = []func(context.Context) error{
+		traceProvider.Shutdown,
+		func(context.Context) error { return closeTraceExporter() },
+		meterProvider.Shutdown,
+	}
 
-	return traceProvider.Shutdown, nil
+	if cfg.logs {
+		logExporter, err := otlploggrpc.New(
+			ctx,
+			otlploggrpc.WithGRPCConn(conn),
+			otlploggrpc.WithHeaders(cfg.exporters.headers),
+			otlploggrpc.WithRetry(otlploggrpc.RetryConfig(exportRetry)),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create log exporter: %w", err)
+		}
+
+		loggerProvider := sdklog.NewLoggerProvider(
+			sdklog.WithResource(res),
+			sdklog.WithProcessor(sdklog.NewBatchProcessor(logExporter)),
+		)
+		global.SetLoggerProvider(loggerProvider)
+		p.shutdownFuncs = append(p.shutdownFuncs, loggerProvider.Shutdown)
+	}
+
+	return p, nil
+}
+
+// Shutdown flushes and stops every pipeline, then closes the collector
+// connection.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, fn := range p.shutdownFuncs {
+		errs = append(errs, fn(ctx))
+	}
+	errs = append(errs, p.conn.Close())
+	return errors.Join(errs...)
+}
+
+// Degraded reports whether telemetry is currently failing to reach the
+// collector, either because the connection is down or because the last
+// span export failed.
+func (p *Provider) Degraded() bool {
+	if p.conn.GetState() == connectivity.TransientFailure {
+		return true
+	}
+	return p.spans != nil && p.spans.failing()
 }
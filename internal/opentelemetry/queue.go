@@ -0,0 +1,119 @@
+package opentelemetry
+
+import (
+	"context"
+	"sync"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// defaultSpanQueueSize matches the queue of the SDK batch processor.
+const defaultSpanQueueSize = 2048
+
+// queuedSpanProcessor hands ended spans to next from a bounded queue of
+// its own. next must block rather than drop when it is busy, as a
+// BatchSpanProcessor created WithBlocking does: spans are then only lost
+// here, once the queue is full, and each of them is reported to onDrop.
+// The SDK processor keeps its own drop count private.
+type queuedSpanProcessor struct {
+	next   sdktrace.SpanProcessor
+	onDrop func()
+
+	mu      sync.RWMutex
+	stopped bool
+	queue   chan queuedSpan
+	drained chan struct{}
+}
+
+// queuedSpan is either a span to hand over or, when flushed is set, a
+// marker closed once every span queued before it was handed over.
+type queuedSpan struct {
+	span    sdktrace.ReadOnlySpan
+	flushed chan struct{}
+}
+
+func newQueuedSpanProcessor(next sdktrace.SpanProcessor, size int, onDrop func()) *queuedSpanProcessor {
+	p := &queuedSpanProcessor{
+		next:    next,
+		onDrop:  onDrop,
+		queue:   make(chan queuedSpan, size),
+		drained: make(chan struct{}),
+	}
+	go p.drain()
+	return p
+}
+
+func (p *queuedSpanProcessor) drain() {
+	defer close(p.drained)
+	for item := range p.queue {
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+		p.next.OnEnd(item.span)
+	}
+}
+
+func (p *queuedSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+func (p *queuedSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if !s.SpanContext().IsSampled() {
+		return
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.stopped {
+		return
+	}
+
+	select {
+	case p.queue <- queuedSpan{span: s}:
+	default:
+		p.onDrop()
+	}
+}
+
+// ForceFlush waits for the queued spans to be handed over, then flushes
+// next.
+func (p *queuedSpanProcessor) ForceFlush(ctx context.Context) error {
+	p.mu.RLock()
+	if p.stopped {
+		p.mu.RUnlock()
+		return nil
+	}
+	flushed := make(chan struct{})
+	select {
+	case p.queue <- queuedSpan{flushed: flushed}:
+		p.mu.RUnlock()
+	case <-ctx.Done():
+		p.mu.RUnlock()
+		return ctx.Err()
+	}
+
+	select {
+	case <-flushed:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return p.next.ForceFlush(ctx)
+}
+
+// Shutdown hands the queued spans over and shuts next down.
+func (p *queuedSpanProcessor) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.stopped {
+		p.stopped = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.drained:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return p.next.Shutdown(ctx)
+}
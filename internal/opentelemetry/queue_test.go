@@ -0,0 +1,90 @@
+package opentelemetry
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// blockingProcessor records the spans it is handed, blocking each call
+// until release is closed.
+type blockingProcessor struct {
+	*tracetest.SpanRecorder
+	release chan struct{}
+}
+
+func (p blockingProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	<-p.release
+	p.SpanRecorder.OnEnd(s)
+}
+
+func TestQueuedSpanProcessor(t *testing.T) {
+	newTracer := func(sampler sdktrace.Sampler, sut sdktrace.SpanProcessor) func() {
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSampler(sampler), sdktrace.WithSpanProcessor(sut)).Tracer("test")
+		return func() {
+			_, span := tracer.Start(context.Background(), "span")
+			span.End()
+		}
+	}
+
+	t.Run("queuedSpanProcessor should count the spans dropped once the queue is full", func(t *testing.T) {
+		next := blockingProcessor{SpanRecorder: tracetest.NewSpanRecorder(), release: make(chan struct{})}
+		var dropped atomic.Int32
+		sut := newQueuedSpanProcessor(next, 1, func() { dropped.Add(1) })
+		end := newTracer(sdktrace.AlwaysSample(), sut)
+
+		ended := 0
+		for dropped.Load() == 0 {
+			end()
+			ended++
+		}
+		close(next.release)
+		err := sut.Shutdown(context.Background())
+
+		if err != nil {
+			t.Fatalf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got := len(next.Ended()); got+int(dropped.Load()) != ended {
+			t.Errorf("expected every one of the %d spans to be handed over or dropped, got %d handed over and %d dropped instead", ended, got, dropped.Load())
+		}
+	})
+
+	t.Run("queuedSpanProcessor should hand every span over while there is room", func(t *testing.T) {
+		next := blockingProcessor{SpanRecorder: tracetest.NewSpanRecorder(), release: make(chan struct{})}
+		close(next.release)
+		var dropped atomic.Int32
+		sut := newQueuedSpanProcessor(next, 8, func() { dropped.Add(1) })
+		end := newTracer(sdktrace.AlwaysSample(), sut)
+
+		for range 5 {
+			end()
+		}
+		err := sut.ForceFlush(context.Background())
+
+		if err != nil {
+			t.Fatalf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got := len(next.Ended()); got != 5 || dropped.Load() != 0 {
+			t.Errorf("expected 5 spans handed over and none dropped, got %d and %d instead", got, dropped.Load())
+		}
+	})
+
+	t.Run("queuedSpanProcessor should skip spans that were not sampled", func(t *testing.T) {
+		next := blockingProcessor{SpanRecorder: tracetest.NewSpanRecorder(), release: make(chan struct{})}
+		close(next.release)
+		sut := newQueuedSpanProcessor(next, 8, func() {})
+		end := newTracer(recordDroppedSampler{sdktrace.NeverSample()}, sut)
+
+		end()
+		_ = sut.Shutdown(context.Background())
+
+		if got := len(next.Ended()); got != 0 {
+			t.Errorf("expected no spans handed over, got %d instead", got)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithSampler sets the head sampler of the tracer provider.
+func WithSampler(sampler sdktrace.Sampler) Option {
+	return func(c *config) {
+		c.sampler = sampler
+	}
+}
+
+// WithKeptSpans exports spans the sampler dropped when they end with an
+// error status or last at least slowerThan. A zero slowerThan disables
+// the latency rule.
+//
+// The decision is made as each span ends, so it keeps individual spans
+// rather than whole traces: the rest of a dropped trace is still dropped.
+func WithKeptSpans(errors bool, slowerThan time.Duration) Option {
+	return func(c *config) {
+		c.keepErrors = errors
+		c.keepSlowerThan = slowerThan
+	}
+}
+
+// samplingFromEnv reads the sampler from OTEL_TRACES_SAMPLER and
+// OTEL_TRACES_SAMPLER_ARG, following the OpenTelemetry SDK semantics, and
+// the kept spans policy from OTEL_TRACES_KEEP_ERRORS and
+// OTEL_TRACES_KEEP_SLOWER_THAN.
+func samplingFromEnv(getEnv func(key string) string) ([]Option, error) {
+	sampler, err := samplerFromEnv(getEnv("OTEL_TRACES_SAMPLER"), getEnv("OTEL_TRACES_SAMPLER_ARG"))
+	if err != nil {
+		return nil, err
+	}
+	opts := []Option{WithSampler(sampler)}
+
+	var keepErrors bool
+	if v := getEnv("OTEL_TRACES_KEEP_ERRORS"); v != "" {
+		keepErrors, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTEL_TRACES_KEEP_ERRORS: %w", err)
+		}
+	}
+
+	var slowerThan time.Duration
+	if v := getEnv("OTEL_TRACES_KEEP_SLOWER_THAN"); v != "" {
+		slowerThan, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTEL_TRACES_KEEP_SLOWER_THAN: %w", err)
+		}
+	}
+
+	if keepErrors || slowerThan > 0 {
+		opts = append(opts, WithKeptSpans(keepErrors, slowerThan))
+	}
+	return opts, nil
+}
+
+func samplerFromEnv(name, arg string) (sdktrace.Sampler, error) {
+	ratio := func() (float64, error) {
+		if arg == "" {
+			return 1, nil
+		}
+		r, err := strconv.ParseFloat(arg, 64)
+		if err != nil || r < 0 || r > 1 {
+			return 0, fmt.Errorf("invalid OTEL_TRACES_SAMPLER_ARG %q: expected a ratio between 0 and 1", arg)
+		}
+		return r, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "always_on":
+		return sdktrace.AlwaysSample(), nil
+	case "always_off":
+		return sdktrace.NeverSample(), nil
+	case "traceidratio":
+		r, err := ratio()
+		if err != nil {
+			return nil, err
+		}
+		return sdktrace.TraceIDRatioBased(r), nil
+	case "", "parentbased_always_on":
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case "parentbased_always_off":
+		return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+	case "parentbased_traceidratio":
+		r, err := ratio()
+		if err != nil {
+			return nil, err
+		}
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(r)), nil
+	default:
+		return nil, fmt.Errorf("unknown OTEL_TRACES_SAMPLER %q", name)
+	}
+}
+
+// recordDroppedSampler records the spans its base sampler drops, without
+// sampling them, so keptSpanProcessor can still look at them once they end.
+type recordDroppedSampler struct {
+	base sdktrace.Sampler
+}
+
+func (s recordDroppedSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	res := s.base.ShouldSample(p)
+	if res.Decision == sdktrace.Drop {
+		res.Decision = sdktrace.RecordOnly
+	}
+	return res
+}
+
+func (s recordDroppedSampler) Description() string {
+	return fmt.Sprintf("RecordDropped{%s}", s.base.Description())
+}
+
+// keptSpanProcessor forwards sampled spans to next, along with the
+// recorded but unsampled spans that failed or were slow.
+type keptSpanProcessor struct {
+	next       sdktrace.SpanProcessor
+	errors     bool
+	slowerThan time.Duration
+}
+
+func (p *keptSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+func (p *keptSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.SpanContext().IsSampled() {
+		p.next.OnEnd(s)
+		return
+	}
+
+	var reason string
+	switch {
+	case p.errors && s.Status().Code == codes.Error:
+		reason = "error"
+	case p.slowerThan > 0 && s.EndTime().Sub(s.StartTime()) >= p.slowerThan:
+		reason = "latency"
+	default:
+		return
+	}
+
+	p.next.OnEnd(keptSpan{ReadOnlySpan: s, reason: reason})
+}
+
+func (p *keptSpanProcessor) Shutdown(ctx context.Context) error {
+	return p.next.Shutdown(ctx)
+}
+
+func (p *keptSpanProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
+
+// keptSpan presents an unsampled span as sampled, so it gets exported,
+// and tells why it was kept.
+type keptSpan struct {
+	sdktrace.ReadOnlySpan
+	reason string
+}
+
+func (s keptSpan) SpanContext() trace.SpanContext {
+	sc := s.ReadOnlySpan.SpanContext()
+	return sc.WithTraceFlags(sc.TraceFlags().WithSampled(true))
+}
+
+func (s keptSpan) Attributes() []attribute.KeyValue {
+	return append(s.ReadOnlySpan.Attributes(), attribute.String("sampling.kept_by", s.reason))
+}
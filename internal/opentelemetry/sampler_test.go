@@ -0,0 +1,155 @@
+package opentelemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSamplerFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		sampler string
+		arg     string
+		want    sdktrace.Sampler
+		wantErr bool
+	}{
+		{name: "samplerFromEnv should default to parent based always on", want: sdktrace.ParentBased(sdktrace.AlwaysSample())},
+		{name: "samplerFromEnv should read always_on", sampler: "always_on", want: sdktrace.AlwaysSample()},
+		{name: "samplerFromEnv should read always_off", sampler: "always_off", want: sdktrace.NeverSample()},
+		{name: "samplerFromEnv should ignore case and spaces", sampler: " ALWAYS_OFF ", want: sdktrace.NeverSample()},
+		{name: "samplerFromEnv should read the ratio", sampler: "traceidratio", arg: "0.25", want: sdktrace.TraceIDRatioBased(0.25)},
+		{name: "samplerFromEnv should default the ratio to 1", sampler: "traceidratio", want: sdktrace.TraceIDRatioBased(1)},
+		{name: "samplerFromEnv should read parentbased_always_off", sampler: "parentbased_always_off", want: sdktrace.ParentBased(sdktrace.NeverSample())},
+		{name: "samplerFromEnv should read parentbased_traceidratio", sampler: "parentbased_traceidratio", arg: "0.1", want: sdktrace.ParentBased(sdktrace.TraceIDRatioBased(0.1))},
+		{name: "samplerFromEnv should reject ratios above 1", sampler: "traceidratio", arg: "1.5", wantErr: true},
+		{name: "samplerFromEnv should reject negative ratios", sampler: "parentbased_traceidratio", arg: "-0.1", wantErr: true},
+		{name: "samplerFromEnv should reject malformed ratios", sampler: "traceidratio", arg: "half", wantErr: true},
+		{name: "samplerFromEnv should reject unknown samplers", sampler: "jaeger_remote", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := samplerFromEnv(tt.sampler, tt.arg)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %s instead", got.Description())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil, got '%v' instead", err)
+			}
+
+			if got.Description() != tt.want.Description() {
+				t.Errorf("expected %s, got %s instead", tt.want.Description(), got.Description())
+			}
+		})
+	}
+}
+
+func TestSamplingFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		wantOpts int
+		wantErr  bool
+	}{
+		{name: "samplingFromEnv should only set the sampler by default", wantOpts: 1},
+		{name: "samplingFromEnv should keep error spans", env: map[string]string{"OTEL_TRACES_KEEP_ERRORS": "true"}, wantOpts: 2},
+		{name: "samplingFromEnv should keep slow spans", env: map[string]string{"OTEL_TRACES_KEEP_SLOWER_THAN": "500ms"}, wantOpts: 2},
+		{name: "samplingFromEnv should ignore a disabled policy", env: map[string]string{"OTEL_TRACES_KEEP_ERRORS": "false", "OTEL_TRACES_KEEP_SLOWER_THAN": "0s"}, wantOpts: 1},
+		{name: "samplingFromEnv should reject malformed booleans", env: map[string]string{"OTEL_TRACES_KEEP_ERRORS": "maybe"}, wantErr: true},
+		{name: "samplingFromEnv should reject malformed durations", env: map[string]string{"OTEL_TRACES_KEEP_SLOWER_THAN": "slow"}, wantErr: true},
+		{name: "samplingFromEnv should reject unknown samplers", env: map[string]string{"OTEL_TRACES_SAMPLER": "sometimes"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := samplingFromEnv(func(key string) string { return tt.env[key] })
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got nil instead")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil, got '%v' instead", err)
+			}
+
+			if len(got) != tt.wantOpts {
+				t.Errorf("expected %d options, got %d instead", tt.wantOpts, len(got))
+			}
+		})
+	}
+}
+
+func TestKeptSpanProcessor(t *testing.T) {
+	tests := []struct {
+		name       string
+		sampler    sdktrace.Sampler
+		errors     bool
+		slowerThan time.Duration
+		failed     bool
+		duration   time.Duration
+		wantKept   bool
+		wantReason string
+	}{
+		{name: "keptSpanProcessor should forward sampled spans untouched", sampler: sdktrace.AlwaysSample(), wantKept: true},
+		{name: "keptSpanProcessor should drop unsampled spans by default", sampler: sdktrace.NeverSample(), errors: true, slowerThan: time.Second},
+		{name: "keptSpanProcessor should keep unsampled spans that failed", sampler: sdktrace.NeverSample(), errors: true, failed: true, wantKept: true, wantReason: "error"},
+		{name: "keptSpanProcessor should ignore failures when not asked to keep them", sampler: sdktrace.NeverSample(), slowerThan: time.Second, failed: true},
+		{name: "keptSpanProcessor should keep unsampled spans that were slow", sampler: sdktrace.NeverSample(), slowerThan: time.Second, duration: 2 * time.Second, wantKept: true, wantReason: "latency"},
+		{name: "keptSpanProcessor should ignore latency when the rule is disabled", sampler: sdktrace.NeverSample(), errors: true, duration: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			sut := &keptSpanProcessor{next: recorder, errors: tt.errors, slowerThan: tt.slowerThan}
+			tracer := sdktrace.NewTracerProvider(
+				sdktrace.WithSampler(recordDroppedSampler{tt.sampler}),
+				sdktrace.WithSpanProcessor(sut),
+			).Tracer("test")
+
+			start := time.Now()
+			_, span := tracer.Start(context.Background(), "span", trace.WithTimestamp(start))
+			if tt.failed {
+				span.SetStatus(codes.Error, "failed")
+			}
+			span.End(trace.WithTimestamp(start.Add(tt.duration)))
+
+			ended := recorder.Ended()
+			if !tt.wantKept {
+				if len(ended) != 0 {
+					t.Errorf("expected the span to be dropped, got %d spans instead", len(ended))
+				}
+				return
+			}
+
+			if len(ended) != 1 {
+				t.Fatalf("expected the span to be kept, got %d spans instead", len(ended))
+			}
+
+			if !ended[0].SpanContext().IsSampled() {
+				t.Errorf("expected the kept span to be marked as sampled")
+			}
+
+			attrs := attribute.NewSet(ended[0].Attributes()...)
+			got, _ := attrs.Value("sampling.kept_by")
+			if got.AsString() != tt.wantReason {
+				t.Errorf("expected sampling.kept_by to be '%s', got '%s' instead", tt.wantReason, got.AsString())
+			}
+		})
+	}
+}
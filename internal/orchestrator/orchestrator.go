@@ -1,28 +1,39 @@
 package orchestrator
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
+	"time"
 
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/allanmaral/go-expert-otel-challenge/internal/webserver"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/breaker"
 	"github.com/allanmaral/go-expert-otel-challenge/pkg/cep"
 	"github.com/allanmaral/go-expert-otel-challenge/pkg/weather"
 )
 
 func New(
-	logger *log.Logger,
+	logger *slog.Logger,
 	tracer trace.Tracer,
+	meter metric.Meter,
+	telemetry webserver.TelemetryStatus,
 	cepLoader cep.Loader,
 	weatherLoader weather.Loader,
+	breakers []*breaker.Breaker,
+	budget time.Duration,
+	auth *webserver.Authenticator,
 ) http.Handler {
 	mux := http.NewServeMux()
-	addRoutes(mux, logger, tracer, cepLoader, weatherLoader)
+	addRoutes(mux, logger, tracer, telemetry, cepLoader, weatherLoader, breakers, auth)
 
 	var handler http.Handler = mux
+	handler = webserver.WithBudget(budget, handler)
+	handler = webserver.WithMetrics(meter, handler)
 	handler = webserver.WithLogging(logger, handler)
 	handler = webserver.WithRequestID(handler)
+	handler = webserver.WithTracing("orchestrator-service", handler)
 
 	return handler
 }
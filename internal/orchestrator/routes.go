@@ -1,33 +1,38 @@
 package orchestrator
 
 import (
+	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 
-	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
-	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/allanmaral/go-expert-otel-challenge/internal/webserver"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/breaker"
 	"github.com/allanmaral/go-expert-otel-challenge/pkg/cep"
 	"github.com/allanmaral/go-expert-otel-challenge/pkg/weather"
 )
 
 func addRoutes(
 	mux *http.ServeMux,
-	logger *log.Logger,
+	logger *slog.Logger,
 	tracer trace.Tracer,
+	telemetry webserver.TelemetryStatus,
 	cepLoader cep.Loader,
 	weatherLoader weather.Loader,
+	breakers []*breaker.Breaker,
+	auth *webserver.Authenticator,
 ) {
-	mux.Handle("POST /api/weather", handleGetTemperature(logger, tracer, cepLoader, weatherLoader))
-	mux.Handle("GET /ready", handleReady())
+	mux.Handle("POST /api/weather", webserver.Route("POST /api/weather", auth.Require("weather", webserver.WithCallerBudget(handleGetTemperature(logger, tracer, cepLoader, weatherLoader)))))
+	mux.Handle("GET /ready", webserver.Route("GET /ready", handleReady(telemetry)))
+	mux.Handle("GET /debug/breakers", webserver.Route("GET /debug/breakers", auth.Require("debug", handleBreakers(breakers))))
 }
 
 func handleGetTemperature(
-	logger *log.Logger,
+	logger *slog.Logger,
 	tracer trace.Tracer,
 	cepLoader cep.Loader,
 	weatherLoader weather.Loader,
@@ -44,30 +49,49 @@ func handleGetTemperature(
 	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		carrier := propagation.HeaderCarrier(r.Header)
-		ctx := otel.GetTextMapPropagator().Extract(r.Context(), carrier)
-		ctx, span := tracer.Start(ctx, "/api/weather")
-		defer span.End()
+		ctx := r.Context()
 
 		input, err := webserver.Decode[request](r)
 		if err != nil {
-			_ = webserver.Encode(w, r, http.StatusBadRequest, webserver.ErrorResponse{Message: "invalid input format"})
+			_ = webserver.EncodeProblem(w, r, webserver.ProblemInvalidInput, "invalid input format")
 			return
 		}
 
-		cepCtx, cepSpan := tracer.Start(ctx, "cep-loader")
+		// When the CEP was seen before, its coordinates are almost certainly
+		// unchanged, so the weather lookup starts right away and is only
+		// discarded if the CEP lookup returns a different location.
+		var speculative <-chan weatherResult
+		var known cep.CEP
+		cancelSpeculative := func() {}
+		if hint, ok := cepLoader.(coordinatesHint); ok {
+			if known, ok = hint.Peek(input.CEP); ok {
+				var specCtx context.Context
+				specCtx, cancelSpeculative = context.WithCancel(ctx)
+				speculative = loadWeatherAsync(specCtx, tracer, weatherLoader, known.Latitude, known.Longitude)
+			}
+		}
+		defer cancelSpeculative()
+
+		// The CEP lookup only gets its share of the budget, so a slow
+		// provider still leaves time for the weather lookup.
+		cepCtx, cancelCEP := withShare(ctx, cepBudgetShare)
+		defer cancelCEP()
+		cepCtx, cepSpan := tracer.Start(cepCtx, "cep-loader")
 		cepRes, err := cepLoader.Load(cepCtx, input.CEP)
 		if err != nil {
-			if errors.Is(err, cep.ErrInvalidCEP) {
-				_ = webserver.Encode(w, r, http.StatusUnprocessableEntity, webserver.ErrorResponse{Message: "invalid zipcode"})
+			if errors.Is(err, context.DeadlineExceeded) {
+				_ = webserver.EncodeProblem(w, r, webserver.ProblemDeadlineExceeded, "the request budget was exhausted while looking up the zipcode")
+				logger.WarnContext(ctx, "request budget exhausted while loading cep", "error", err)
+			} else if errors.Is(err, cep.ErrInvalidCEP) {
+				_ = webserver.EncodeProblem(w, r, webserver.ProblemInvalidZipcode, "invalid zipcode")
 			} else if errors.Is(err, cep.ErrCEPNotFound) {
-				_ = webserver.Encode(w, r, http.StatusNotFound, webserver.ErrorResponse{Message: "can not find zipcode"})
+				_ = webserver.EncodeProblem(w, r, webserver.ProblemZipcodeNotFound, "can not find zipcode")
 			} else if errors.Is(err, cep.ErrServiceUnavailable) {
-				_ = webserver.Encode(w, r, http.StatusBadGateway, webserver.ErrorResponse{Message: "cep service is unavailable, try again later"})
-				logger.Printf("cep service is unavailable %s\n", err)
+				_ = webserver.EncodeProblem(w, r, webserver.ProblemCEPUnavailable, "cep service is unavailable, try again later")
+				logger.ErrorContext(ctx, "cep service is unavailable", "error", err)
 			} else {
-				_ = webserver.Encode(w, r, http.StatusInternalServerError, webserver.ErrorResponse{Message: "internal server error"})
-				logger.Printf("unhandled error while loading cep %s\n", err)
+				_ = webserver.EncodeProblem(w, r, webserver.ProblemInternal, "internal server error")
+				logger.ErrorContext(ctx, "unhandled error while loading cep", "error", err)
 			}
 			cepSpan.SetStatus(codes.Error, "cep loader failed")
 			cepSpan.RecordError(err)
@@ -76,22 +100,30 @@ func handleGetTemperature(
 		}
 		cepSpan.End()
 
-		weatherCtx, weatherSpan := tracer.Start(ctx, "weather-loader")
-		weatherRes, err := weatherLoader.Load(weatherCtx, cepRes.Latitude, cepRes.Longitude)
+		var weatherRes weather.Weather
+		if speculative != nil && known.Latitude == cepRes.Latitude && known.Longitude == cepRes.Longitude {
+			res := <-speculative
+			weatherRes, err = res.weather, res.err
+		} else {
+			cancelSpeculative()
+			weatherRes, err = loadWeather(ctx, tracer, weatherLoader, cepRes.Latitude, cepRes.Longitude, false)
+		}
 		if err != nil {
-			if errors.Is(err, weather.ErrServiceUnavailable) {
-				_ = webserver.Encode(w, r, http.StatusBadGateway, webserver.ErrorResponse{Message: "weather service is unavailable, try again later"})
-				logger.Printf("weather service in unavailable %s\n", err)
+			if errors.Is(err, context.DeadlineExceeded) {
+				_ = webserver.EncodeProblem(w, r, webserver.ProblemDeadlineExceeded, "the request budget was exhausted while looking up the weather")
+				logger.WarnContext(ctx, "request budget exhausted while loading weather", "error", err)
+			} else if errors.Is(err, weather.ErrInvalidLocation) {
+				_ = webserver.EncodeProblem(w, r, webserver.ProblemInvalidLocation, "the weather service could not locate the zipcode")
+				logger.WarnContext(ctx, "weather service rejected the zipcode location", "error", err, "latitude", cepRes.Latitude, "longitude", cepRes.Longitude)
+			} else if errors.Is(err, weather.ErrServiceUnavailable) || errors.Is(err, weather.ErrUnauthorized) {
+				_ = webserver.EncodeProblem(w, r, webserver.ProblemWeatherUnavailable, "weather service is unavailable, try again later")
+				logger.ErrorContext(ctx, "weather service is unavailable", "error", err)
 			} else {
-				_ = webserver.Encode(w, r, http.StatusInternalServerError, webserver.ErrorResponse{Message: "internal server error"})
-				logger.Printf("unhandled error while loading weather %s\n", err)
+				_ = webserver.EncodeProblem(w, r, webserver.ProblemInternal, "internal server error")
+				logger.ErrorContext(ctx, "unhandled error while loading weather", "error", err)
 			}
-			cepSpan.SetStatus(codes.Error, "weather loader failed")
-			weatherSpan.RecordError(err)
-			weatherSpan.End()
 			return
 		}
-		weatherSpan.End()
 
 		resp := response{
 			City:  cepRes.City,
@@ -104,10 +136,85 @@ func handleGetTemperature(
 	})
 }
 
-func handleReady() http.Handler {
+// cepBudgetShare is the part of the remaining request budget given to the
+// CEP lookup. The weather lookup gets whatever is left once it answers.
+const cepBudgetShare = 0.5
+
+// withShare bounds ctx to share of the time left before its deadline.
+func withShare(ctx context.Context, share float64) (context.Context, context.CancelFunc) {
+	if timeout, ok := webserver.Remaining(ctx, share); ok {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// coordinatesHint is implemented by CEP loaders that remember the last
+// known address of a CEP, such as cep.CachedLoader.
+type coordinatesHint interface {
+	Peek(cep string) (cep.CEP, bool)
+}
+
+type weatherResult struct {
+	weather weather.Weather
+	err     error
+}
+
+func loadWeatherAsync(
+	ctx context.Context,
+	tracer trace.Tracer,
+	loader weather.Loader,
+	lat, lng string,
+) <-chan weatherResult {
+	ch := make(chan weatherResult, 1)
+	go func() {
+		w, err := loadWeather(ctx, tracer, loader, lat, lng, true)
+		ch <- weatherResult{weather: w, err: err}
+	}()
+	return ch
+}
+
+func loadWeather(
+	ctx context.Context,
+	tracer trace.Tracer,
+	loader weather.Loader,
+	lat, lng string,
+	speculative bool,
+) (weather.Weather, error) {
+	ctx, span := tracer.Start(ctx, "weather-loader", trace.WithAttributes(
+		attribute.Bool("weather.speculative", speculative),
+	))
+	defer span.End()
+
+	w, err := loader.Load(ctx, lat, lng)
+	if err != nil {
+		span.SetStatus(codes.Error, "weather loader failed")
+		span.RecordError(err)
+	}
+	return w, err
+}
+
+func handleReady(telemetry webserver.TelemetryStatus) http.Handler {
 	return http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
+			_ = webserver.Encode(w, r, http.StatusOK, webserver.NewReadyResponse(telemetry))
+		},
+	)
+}
+
+func handleBreakers(breakers []*breaker.Breaker) http.Handler {
+	type response struct {
+		Name   string         `json:"name"`
+		State  string         `json:"state"`
+		Counts breaker.Counts `json:"counts"`
+	}
+
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			res := make([]response, 0, len(breakers))
+			for _, b := range breakers {
+				res = append(res, response{Name: b.Name(), State: b.State().String(), Counts: b.Counts()})
+			}
+			_ = webserver.Encode(w, r, http.StatusOK, res)
 		},
 	)
 }
@@ -0,0 +1,306 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/webserver"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/cep"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/weather"
+)
+
+type fakeCEPLoader struct {
+	load func(ctx context.Context, cep string) (cep.CEP, error)
+}
+
+func (l *fakeCEPLoader) Load(ctx context.Context, c string) (cep.CEP, error) {
+	return l.load(ctx, c)
+}
+
+// hintedCEPLoader also remembers the last known address of a CEP, like
+// cep.CachedLoader.
+type hintedCEPLoader struct {
+	fakeCEPLoader
+	known *cep.CEP
+}
+
+func (l *hintedCEPLoader) Peek(c string) (cep.CEP, bool) {
+	if l.known == nil {
+		return cep.CEP{}, false
+	}
+	return *l.known, true
+}
+
+type weatherCall struct {
+	lat, lng string
+}
+
+type fakeWeatherLoader struct {
+	load func(ctx context.Context, lat, lng string) (weather.Weather, error)
+
+	mu    sync.Mutex
+	calls []weatherCall
+}
+
+func (l *fakeWeatherLoader) Load(ctx context.Context, lat, lng string) (weather.Weather, error) {
+	w, err := l.load(ctx, lat, lng)
+	l.mu.Lock()
+	l.calls = append(l.calls, weatherCall{lat: lat, lng: lng})
+	l.mu.Unlock()
+	return w, err
+}
+
+func (l *fakeWeatherLoader) recorded() []weatherCall {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]weatherCall(nil), l.calls...)
+}
+
+var (
+	knownCEP = cep.CEP{Cep: "25808110", City: "Três Rios", Latitude: "-22.1", Longitude: "-43.2"}
+	movedCEP = cep.CEP{Cep: "25808110", City: "Três Rios", Latitude: "-22.5", Longitude: "-43.5"}
+)
+
+func returningCEP(c cep.CEP) fakeCEPLoader {
+	return fakeCEPLoader{load: func(ctx context.Context, _ string) (cep.CEP, error) {
+		return c, nil
+	}}
+}
+
+func temperatureAt(lat string) float64 {
+	if lat == movedCEP.Latitude {
+		return 30
+	}
+	return 20
+}
+
+func getTemperature(t *testing.T, cepLoader cep.Loader, weatherLoader weather.Loader) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	return getTemperatureWithin(t, 0, cepLoader, weatherLoader)
+}
+
+// getTemperatureWithin bounds the request by budget, or leaves it
+// unbounded when budget is zero.
+func getTemperatureWithin(t *testing.T, budget time.Duration, cepLoader cep.Loader, weatherLoader weather.Loader) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var sut http.Handler = handleGetTemperature(logger, noop.NewTracerProvider().Tracer("test"), cepLoader, weatherLoader)
+	if budget > 0 {
+		sut = webserver.WithBudget(budget, sut)
+	}
+	w := httptest.NewRecorder()
+	sut.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/weather", strings.NewReader(`{"cep":"25808110"}`)))
+
+	var body map[string]any
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	return w, body
+}
+
+func TestHandleGetTemperature_Speculative(t *testing.T) {
+	t.Run("Handler should look the weather up after the CEP when its location is unknown", func(t *testing.T) {
+		cepLoader := &hintedCEPLoader{fakeCEPLoader: returningCEP(knownCEP)}
+		weatherLoader := &fakeWeatherLoader{load: func(ctx context.Context, lat, lng string) (weather.Weather, error) {
+			return weather.Weather{TempC: temperatureAt(lat)}, nil
+		}}
+
+		w, body := getTemperature(t, cepLoader, weatherLoader)
+
+		if w.Code != http.StatusOK || body["temp_C"] != 20.0 {
+			t.Errorf("expected 200 with temp_C 20, got %d %v instead", w.Code, body)
+		}
+
+		if calls := weatherLoader.recorded(); len(calls) != 1 || calls[0].lat != knownCEP.Latitude {
+			t.Errorf("expected one lookup at the CEP location, got %+v instead", calls)
+		}
+	})
+
+	t.Run("Handler should start the weather lookup before the CEP answers when its location is known", func(t *testing.T) {
+		started := make(chan struct{})
+		cepLoader := &hintedCEPLoader{
+			fakeCEPLoader: fakeCEPLoader{load: func(ctx context.Context, _ string) (cep.CEP, error) {
+				select {
+				case <-started:
+					return knownCEP, nil
+				case <-time.After(time.Second):
+					return cep.CEP{}, cep.ErrServiceUnavailable
+				}
+			}},
+			known: &knownCEP,
+		}
+		weatherLoader := &fakeWeatherLoader{load: func(ctx context.Context, lat, lng string) (weather.Weather, error) {
+			close(started)
+			return weather.Weather{TempC: temperatureAt(lat)}, nil
+		}}
+
+		w, body := getTemperature(t, cepLoader, weatherLoader)
+
+		if w.Code != http.StatusOK || body["temp_C"] != 20.0 {
+			t.Errorf("expected 200 with the speculative temp_C 20, got %d %v instead", w.Code, body)
+		}
+
+		if calls := weatherLoader.recorded(); len(calls) != 1 {
+			t.Errorf("expected the speculative lookup to be used, got %d lookups instead", len(calls))
+		}
+	})
+
+	t.Run("Handler should cancel the speculative lookup when the location changed", func(t *testing.T) {
+		cepLoader := &hintedCEPLoader{fakeCEPLoader: returningCEP(movedCEP), known: &knownCEP}
+		canceled := make(chan struct{})
+		weatherLoader := &fakeWeatherLoader{load: func(ctx context.Context, lat, lng string) (weather.Weather, error) {
+			if lat == knownCEP.Latitude {
+				<-ctx.Done()
+				close(canceled)
+				return weather.Weather{}, ctx.Err()
+			}
+			return weather.Weather{TempC: temperatureAt(lat)}, nil
+		}}
+
+		w, body := getTemperature(t, cepLoader, weatherLoader)
+
+		if w.Code != http.StatusOK || body["temp_C"] != 30.0 {
+			t.Errorf("expected 200 with temp_C 30 at the new location, got %d %v instead", w.Code, body)
+		}
+
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			t.Errorf("expected the speculative lookup to be cancelled")
+		}
+	})
+
+	t.Run("Handler should hand back the error of the speculative lookup", func(t *testing.T) {
+		cepLoader := &hintedCEPLoader{fakeCEPLoader: returningCEP(knownCEP), known: &knownCEP}
+		weatherLoader := &fakeWeatherLoader{load: func(ctx context.Context, lat, lng string) (weather.Weather, error) {
+			return weather.Weather{}, weather.ErrServiceUnavailable
+		}}
+
+		w, body := getTemperature(t, cepLoader, weatherLoader)
+
+		if w.Code != http.StatusBadGateway || body["code"] != webserver.ProblemWeatherUnavailable.Code {
+			t.Errorf("expected 502 weather_unavailable, got %d %v instead", w.Code, body)
+		}
+
+		if calls := weatherLoader.recorded(); len(calls) != 1 {
+			t.Errorf("expected the failed lookup not to be repeated, got %d lookups instead", len(calls))
+		}
+	})
+
+	t.Run("Handler should not start a speculative lookup for loaders without hints", func(t *testing.T) {
+		cepLoader := &fakeCEPLoader{load: func(ctx context.Context, _ string) (cep.CEP, error) {
+			return cep.CEP{}, cep.ErrCEPNotFound
+		}}
+		weatherLoader := &fakeWeatherLoader{load: func(ctx context.Context, lat, lng string) (weather.Weather, error) {
+			return weather.Weather{}, nil
+		}}
+
+		w, _ := getTemperature(t, cepLoader, weatherLoader)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d instead", http.StatusNotFound, w.Code)
+		}
+
+		if calls := weatherLoader.recorded(); len(calls) != 0 {
+			t.Errorf("expected no weather lookup, got %d instead", len(calls))
+		}
+	})
+}
+
+func TestWithShare(t *testing.T) {
+	t.Run("withShare should bound the context to its share of the time left", func(t *testing.T) {
+		parent, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		sut, cancelShare := withShare(parent, cepBudgetShare)
+		defer cancelShare()
+
+		deadline, ok := sut.Deadline()
+		if !ok {
+			t.Fatalf("expected a deadline")
+		}
+		if left := time.Until(deadline); left > 500*time.Millisecond || left < 400*time.Millisecond {
+			t.Errorf("expected about 500ms left, got '%v' instead", left)
+		}
+	})
+
+	t.Run("withShare should leave contexts without a deadline unbounded", func(t *testing.T) {
+		sut, cancel := withShare(context.Background(), cepBudgetShare)
+		defer cancel()
+
+		if _, ok := sut.Deadline(); ok {
+			t.Errorf("expected no deadline")
+		}
+	})
+}
+
+func TestHandleGetTemperature_Budget(t *testing.T) {
+	t.Run("Handler should give the CEP lookup only its share of the budget", func(t *testing.T) {
+		var cepDeadline, weatherDeadline time.Time
+		cepLoader := &fakeCEPLoader{load: func(ctx context.Context, _ string) (cep.CEP, error) {
+			cepDeadline, _ = ctx.Deadline()
+			return knownCEP, nil
+		}}
+		weatherLoader := &fakeWeatherLoader{load: func(ctx context.Context, lat, lng string) (weather.Weather, error) {
+			weatherDeadline, _ = ctx.Deadline()
+			return weather.Weather{TempC: temperatureAt(lat)}, nil
+		}}
+
+		start := time.Now()
+		w, _ := getTemperatureWithin(t, time.Second, cepLoader, weatherLoader)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d instead", http.StatusOK, w.Code)
+		}
+		if share := cepDeadline.Sub(start); share > 600*time.Millisecond || share < 400*time.Millisecond {
+			t.Errorf("expected the CEP lookup to get about 500ms, got '%v' instead", share)
+		}
+		if !weatherDeadline.After(cepDeadline) {
+			t.Errorf("expected the weather lookup to get the rest of the budget, got '%v' instead", weatherDeadline.Sub(start))
+		}
+	})
+
+	tests := []struct {
+		name          string
+		cepLoader     cep.Loader
+		weatherLoader weather.Loader
+	}{
+		{
+			name: "Handler should answer deadline exceeded when the CEP lookup runs out of its share",
+			cepLoader: &fakeCEPLoader{load: func(ctx context.Context, _ string) (cep.CEP, error) {
+				<-ctx.Done()
+				return cep.CEP{}, ctx.Err()
+			}},
+			weatherLoader: &fakeWeatherLoader{load: func(ctx context.Context, lat, lng string) (weather.Weather, error) {
+				return weather.Weather{}, nil
+			}},
+		},
+		{
+			name:      "Handler should answer deadline exceeded when the weather lookup runs out of budget",
+			cepLoader: &fakeCEPLoader{load: returningCEP(knownCEP).load},
+			weatherLoader: &fakeWeatherLoader{load: func(ctx context.Context, lat, lng string) (weather.Weather, error) {
+				<-ctx.Done()
+				return weather.Weather{}, ctx.Err()
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, body := getTemperatureWithin(t, 50*time.Millisecond, tt.cepLoader, tt.weatherLoader)
+
+			if w.Code != http.StatusGatewayTimeout || body["code"] != webserver.ProblemDeadlineExceeded.Code {
+				t.Errorf("expected 504 deadline_exceeded, got %d %v instead", w.Code, body)
+			}
+		})
+	}
+}
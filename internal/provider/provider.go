@@ -0,0 +1,53 @@
+// Package provider holds the helpers shared by the composite loaders of
+// pkg/cep and pkg/weather.
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name returns the provider name of loaders that expose one, falling back
+// to the loader type.
+func Name(l any) string {
+	if n, ok := l.(interface{ Name() string }); ok {
+		return n.Name()
+	}
+	return fmt.Sprintf("%T", l)
+}
+
+// Warmup warms l up when it can open its provider connections ahead of
+// the first lookup and is a no-op otherwise.
+func Warmup(ctx context.Context, l any) error {
+	if w, ok := l.(interface{ Warmup(context.Context) error }); ok {
+		return w.Warmup(ctx)
+	}
+	return nil
+}
+
+// WarmupAll warms up every loader, reporting the failures by name.
+func WarmupAll[L any](ctx context.Context, loaders []L) error {
+	errs := make([]error, 0, len(loaders))
+	for _, l := range loaders {
+		if err := Warmup(ctx, l); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", Name(l), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// EndAttempt ends the span of a provider attempt, recording its outcome
+// and, when it failed, err with the failure description.
+func EndAttempt(span trace.Span, outcome attribute.KeyValue, err error, failure string) {
+	span.SetAttributes(outcome)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, failure)
+	}
+	span.End()
+}
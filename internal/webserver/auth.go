@@ -0,0 +1,193 @@
+package webserver
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// APIKeyHeader is the alternative to "Authorization: Bearer <key>".
+const APIKeyHeader = "X-API-Key"
+
+// Identity is the client behind a verified API key. A client without
+// scopes may use every route.
+type Identity struct {
+	Client string
+	Scopes []string
+}
+
+// Allows reports whether the identity may use routes requiring scope.
+func (i Identity) Allows(scope string) bool {
+	return len(i.Scopes) == 0 || slices.Contains(i.Scopes, scope)
+}
+
+type ctxKeyIdentity int
+
+const identityKey ctxKeyIdentity = 0
+
+// GetIdentity returns the client authenticated for the request of ctx.
+func GetIdentity(ctx context.Context) (Identity, bool) {
+	if ctx == nil {
+		return Identity{}, false
+	}
+	identity, ok := ctx.Value(identityKey).(Identity)
+	return identity, ok
+}
+
+// KeyStore maps API keys to the clients they belong to. Only hashes of
+// the keys are kept in memory.
+type KeyStore struct {
+	keys map[[sha256.Size]byte]Identity
+}
+
+func NewKeyStore() *KeyStore {
+	return &KeyStore{keys: make(map[[sha256.Size]byte]Identity)}
+}
+
+// Add registers key for client, restricted to scopes when any is given.
+func (s *KeyStore) Add(client, key string, scopes ...string) error {
+	if client == "" || key == "" {
+		return fmt.Errorf("client and key must not be empty")
+	}
+	hash := sha256.Sum256([]byte(key))
+	if _, ok := s.keys[hash]; ok {
+		return fmt.Errorf("duplicated key for client %q", client)
+	}
+	s.keys[hash] = Identity{Client: client, Scopes: scopes}
+	return nil
+}
+
+// Len returns the number of registered keys.
+func (s *KeyStore) Len() int {
+	return len(s.keys)
+}
+
+func (s *KeyStore) lookup(key string) (Identity, bool) {
+	identity, ok := s.keys[sha256.Sum256([]byte(key))]
+	return identity, ok
+}
+
+// ParseKeys registers keys written as "<client>:<key>[:<scope>|<scope>]",
+// separated by commas.
+func (s *KeyStore) ParseKeys(v string) error {
+	for _, entry := range strings.Split(v, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		if err := s.parseEntry(strings.Split(entry, ":")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFile registers the keys of a file holding one
+// "<client> <key> [<scope>,<scope>]" entry per line. Blank lines and
+// lines starting with "#" are skipped.
+func (s *KeyStore) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open key file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) == 3 {
+			fields[2] = strings.ReplaceAll(fields[2], ",", "|")
+		}
+		if err := s.parseEntry(fields); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *KeyStore) parseEntry(fields []string) error {
+	if len(fields) < 2 || len(fields) > 3 {
+		return fmt.Errorf("invalid key entry: expected a client, a key and optional scopes")
+	}
+	var scopes []string
+	if len(fields) == 3 {
+		scopes = strings.Split(fields[2], "|")
+	}
+	return s.Add(fields[0], fields[1], scopes...)
+}
+
+// Authenticator checks the API key of every request against a KeyStore.
+type Authenticator struct {
+	store *KeyStore
+	realm string
+}
+
+// NewAuthenticator verifies keys against store. realm names the service
+// in the WWW-Authenticate challenge.
+func NewAuthenticator(store *KeyStore, realm string) *Authenticator {
+	return &Authenticator{store: store, realm: realm}
+}
+
+// Require only lets through requests carrying a known key, sent as
+// "Authorization: Bearer <key>" or in X-API-Key, whose client is allowed
+// scope. Missing or unknown keys get a 401 problem response and clients
+// lacking the scope a 403 one. The client is attached to the request
+// context and to its span as enduser.id. A nil Authenticator lets every
+// request through.
+func (a *Authenticator) Require(scope string, next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+
+	challenge := fmt.Sprintf("Bearer realm=%q", a.realm)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := credential(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", challenge)
+			_ = EncodeProblem(w, r, ProblemUnauthorized, "an API key is required")
+			return
+		}
+
+		identity, ok := a.store.lookup(key)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", challenge+`, error="invalid_token"`)
+			_ = EncodeProblem(w, r, ProblemUnauthorized, "the API key is not valid")
+			return
+		}
+
+		span := trace.SpanFromContext(r.Context())
+		span.SetAttributes(attribute.String("enduser.id", identity.Client))
+		if !identity.Allows(scope) {
+			_ = EncodeProblem(w, r, ProblemForbidden, "the API key is not allowed to use this route")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), identityKey, identity)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// credential extracts the API key of r. An Authorization header using
+// another scheme counts as no key at all.
+func credential(r *http.Request) (string, bool) {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		scheme, key, ok := strings.Cut(auth, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return "", false
+		}
+		key = strings.TrimSpace(key)
+		return key, key != ""
+	}
+	key := r.Header.Get(APIKeyHeader)
+	return key, key != ""
+}
@@ -0,0 +1,228 @@
+package webserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestKeyStore_ParseKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		key     string
+		want    Identity
+		wantErr bool
+	}{
+		{name: "ParseKeys should read a key without scopes", value: "partner:secret", key: "secret", want: Identity{Client: "partner"}},
+		{name: "ParseKeys should read the scopes", value: "partner:secret:weather|debug", key: "secret", want: Identity{Client: "partner", Scopes: []string{"weather", "debug"}}},
+		{name: "ParseKeys should read several entries", value: "a:one, b:two ,", key: "two", want: Identity{Client: "b"}},
+		{name: "ParseKeys should accept an empty value", value: ""},
+		{name: "ParseKeys should require a key", value: "partner", wantErr: true},
+		{name: "ParseKeys should reject empty keys", value: "partner:", wantErr: true},
+		{name: "ParseKeys should reject extra fields", value: "partner:secret:weather:debug", wantErr: true},
+		{name: "ParseKeys should reject duplicated keys", value: "a:secret,b:secret", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sut := NewKeyStore()
+
+			err := sut.ParseKeys(tt.value)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got nil instead")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil, got '%v' instead", err)
+			}
+
+			if tt.key == "" {
+				if sut.Len() != 0 {
+					t.Errorf("expected no keys, got %d instead", sut.Len())
+				}
+				return
+			}
+
+			got, ok := sut.lookup(tt.key)
+			if !ok || got.Client != tt.want.Client || !slices.Equal(got.Scopes, tt.want.Scopes) {
+				t.Errorf("expected %+v, got %+v instead", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestKeyStore_LoadFile(t *testing.T) {
+	writeKeys := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "keys")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("could not write key file: %v", err)
+		}
+		return path
+	}
+
+	t.Run("LoadFile should read entries and skip comments and blank lines", func(t *testing.T) {
+		sut := NewKeyStore()
+		path := writeKeys(t, "# partners\n\npartner-a secret-a\npartner-b secret-b weather,debug\n")
+
+		err := sut.LoadFile(path)
+
+		if err != nil {
+			t.Fatalf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if sut.Len() != 2 {
+			t.Errorf("expected 2 keys, got %d instead", sut.Len())
+		}
+
+		got, _ := sut.lookup("secret-b")
+		if got.Client != "partner-b" || !slices.Equal(got.Scopes, []string{"weather", "debug"}) {
+			t.Errorf("expected partner-b with weather and debug scopes, got %+v instead", got)
+		}
+	})
+
+	t.Run("LoadFile should report the line of an invalid entry", func(t *testing.T) {
+		sut := NewKeyStore()
+		path := writeKeys(t, "partner-a secret-a\npartner-b\n")
+
+		err := sut.LoadFile(path)
+
+		if err == nil || err.Error() != path+":2: invalid key entry: expected a client, a key and optional scopes" {
+			t.Errorf("expected error on line 2, got '%v' instead", err)
+		}
+	})
+
+	t.Run("LoadFile should fail on a missing file", func(t *testing.T) {
+		sut := NewKeyStore()
+
+		err := sut.LoadFile(filepath.Join(t.TempDir(), "missing"))
+
+		if err == nil {
+			t.Errorf("expected error, got nil instead")
+		}
+	})
+}
+
+func TestCredential(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+		wantOK  bool
+	}{
+		{name: "credential should read a bearer token", headers: map[string]string{"Authorization": "Bearer secret"}, want: "secret", wantOK: true},
+		{name: "credential should accept any case in the scheme", headers: map[string]string{"Authorization": "bearer  secret "}, want: "secret", wantOK: true},
+		{name: "credential should read the API key header", headers: map[string]string{APIKeyHeader: "secret"}, want: "secret", wantOK: true},
+		{name: "credential should prefer the Authorization header", headers: map[string]string{"Authorization": "Bearer one", APIKeyHeader: "two"}, want: "one", wantOK: true},
+		{name: "credential should reject other schemes", headers: map[string]string{"Authorization": "Basic c2VjcmV0", APIKeyHeader: "secret"}},
+		{name: "credential should reject an empty bearer token", headers: map[string]string{"Authorization": "Bearer "}},
+		{name: "credential should reject a scheme without a token", headers: map[string]string{"Authorization": "Bearer"}},
+		{name: "credential should report a missing key", headers: map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			got, ok := credential(r)
+
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("expected ('%s', %t), got ('%s', %t) instead", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_Require(t *testing.T) {
+	store := NewKeyStore()
+	_ = store.Add("partner", "weather-key", "weather")
+	_ = store.Add("admin", "admin-key")
+	_ = store.Add("monitor", "debug-key", "debug")
+
+	var client string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, _ := GetIdentity(r.Context())
+		client = identity.Client
+		w.WriteHeader(http.StatusNoContent)
+	})
+	sut := NewAuthenticator(store, "test").Require("weather", next)
+
+	tests := []struct {
+		name       string
+		key        string
+		wantStatus int
+		wantClient string
+		challenge  string
+	}{
+		{name: "Require should let a key with the scope through", key: "weather-key", wantStatus: http.StatusNoContent, wantClient: "partner"},
+		{name: "Require should let a key without scopes through", key: "admin-key", wantStatus: http.StatusNoContent, wantClient: "admin"},
+		{name: "Require should answer 403 to a key lacking the scope", key: "debug-key", wantStatus: http.StatusForbidden},
+		{name: "Require should answer 401 to an unknown key", key: "wrong-key", wantStatus: http.StatusUnauthorized, challenge: `Bearer realm="test", error="invalid_token"`},
+		{name: "Require should answer 401 to a missing key", wantStatus: http.StatusUnauthorized, challenge: `Bearer realm="test"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client = ""
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.key != "" {
+				r.Header.Set(APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+
+			sut.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d instead", tt.wantStatus, w.Code)
+			}
+
+			if client != tt.wantClient {
+				t.Errorf("expected client '%s', got '%s' instead", tt.wantClient, client)
+			}
+
+			if got := w.Header().Get("WWW-Authenticate"); got != tt.challenge {
+				t.Errorf("expected challenge '%s', got '%s' instead", tt.challenge, got)
+			}
+		})
+	}
+
+	t.Run("Require should let every request through on a nil Authenticator", func(t *testing.T) {
+		var auth *Authenticator
+		w := httptest.NewRecorder()
+
+		auth.Require("weather", next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("expected status %d, got %d instead", http.StatusNoContent, w.Code)
+		}
+	})
+
+	t.Run("Require should be guarded by a rate limiter counting failed attempts", func(t *testing.T) {
+		limiter := NewRateLimiter(NewMemoryRateLimitStore(), RateLimitByIP, RateLimit{Requests: 2, Period: time.Minute}, nil)
+		guarded := limiter.Limit("GET /", sut)
+		var w *httptest.ResponseRecorder
+
+		for range 3 {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(APIKeyHeader, "guess")
+			w = httptest.NewRecorder()
+			guarded.ServeHTTP(w, r)
+		}
+
+		if w.Code != http.StatusTooManyRequests {
+			t.Errorf("expected status %d, got %d instead", http.StatusTooManyRequests, w.Code)
+		}
+	})
+}
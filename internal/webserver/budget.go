@@ -0,0 +1,64 @@
+package webserver
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// BudgetHeader carries how many milliseconds the caller is still willing
+// to wait. A relative budget is used instead of an absolute deadline so
+// clock skew between hosts does not matter.
+const BudgetHeader = "X-Request-Budget"
+
+// WithBudget bounds every request by budget. Handlers see it as the
+// deadline of the request context. BudgetHeader is ignored here, see
+// WithCallerBudget.
+func WithBudget(budget time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), budget)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// WithCallerBudget shortens the request deadline to whatever the caller
+// announced through BudgetHeader. It must only wrap handlers reached by
+// trusted callers, behind Authenticator.Require: an anonymous client
+// sending a zero budget would otherwise make every provider call time out
+// and trip the provider circuit breakers for everyone.
+func WithCallerBudget(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ms, err := strconv.ParseInt(r.Header.Get(BudgetHeader), 10, 64)
+		if err != nil || ms < 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(ms)*time.Millisecond)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// SetBudget announces the time left before the deadline of ctx, minus
+// margin kept to handle the answer, so the next service gives up first.
+func SetBudget(ctx context.Context, h http.Header, margin time.Duration) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return
+	}
+	remaining := max(time.Until(deadline)-margin, 0)
+	h.Set(BudgetHeader, strconv.FormatInt(remaining.Milliseconds(), 10))
+}
+
+// Remaining returns share of the time left before the deadline of ctx,
+// or false when ctx has no deadline.
+func Remaining(ctx context.Context, share float64) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(float64(time.Until(deadline)) * share), true
+}
@@ -0,0 +1,134 @@
+package webserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// deadlineOf serves r through handler and returns how long the request
+// context had left when it reached the inner handler.
+func deadlineOf(t *testing.T, wrap func(http.Handler) http.Handler, r *http.Request) (time.Duration, bool) {
+	t.Helper()
+
+	var remaining time.Duration
+	var ok bool
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var deadline time.Time
+		deadline, ok = r.Context().Deadline()
+		remaining = time.Until(deadline)
+	})
+	wrap(inner).ServeHTTP(httptest.NewRecorder(), r)
+	return remaining, ok
+}
+
+func TestWithBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+	}{
+		{name: "WithBudget should bound requests without a header", header: "", want: time.Second},
+		{name: "WithBudget should ignore a shorter caller budget", header: "0", want: time.Second},
+		{name: "WithBudget should ignore a longer caller budget", header: "60000", want: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set(BudgetHeader, tt.header)
+			}
+
+			got, ok := deadlineOf(t, func(next http.Handler) http.Handler { return WithBudget(time.Second, next) }, r)
+
+			if !ok {
+				t.Fatalf("expected the request to have a deadline")
+			}
+
+			if got > tt.want || got < tt.want-100*time.Millisecond {
+				t.Errorf("expected about %s left, got %s instead", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWithCallerBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+	}{
+		{name: "WithCallerBudget should shorten the budget to the caller budget", header: "200", want: 200 * time.Millisecond},
+		{name: "WithCallerBudget should accept a zero budget", header: "0", want: 0},
+		{name: "WithCallerBudget should never extend the budget", header: "60000", want: time.Second},
+		{name: "WithCallerBudget should ignore negative budgets", header: "-5", want: time.Second},
+		{name: "WithCallerBudget should ignore malformed budgets", header: "1s", want: time.Second},
+		{name: "WithCallerBudget should keep the budget without a header", header: "", want: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set(BudgetHeader, tt.header)
+			}
+
+			got, ok := deadlineOf(t, func(next http.Handler) http.Handler {
+				return WithBudget(time.Second, WithCallerBudget(next))
+			}, r)
+
+			if !ok {
+				t.Fatalf("expected the request to have a deadline")
+			}
+
+			if got > tt.want || got < tt.want-100*time.Millisecond {
+				t.Errorf("expected about %s left, got %s instead", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSetBudget(t *testing.T) {
+	t.Run("SetBudget should announce the time left minus the margin", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		h := http.Header{}
+
+		SetBudget(ctx, h, 100*time.Millisecond)
+
+		ms, err := strconv.ParseInt(h.Get(BudgetHeader), 10, 64)
+		if err != nil {
+			t.Fatalf("expected a numeric budget, got '%s' instead", h.Get(BudgetHeader))
+		}
+
+		if ms > 900 || ms < 800 {
+			t.Errorf("expected about 900ms, got %dms instead", ms)
+		}
+	})
+
+	t.Run("SetBudget should never announce a negative budget", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		h := http.Header{}
+
+		SetBudget(ctx, h, time.Second)
+
+		if got := h.Get(BudgetHeader); got != "0" {
+			t.Errorf("expected budget to be 0, got '%s' instead", got)
+		}
+	})
+
+	t.Run("SetBudget should not announce a budget without a deadline", func(t *testing.T) {
+		h := http.Header{}
+
+		SetBudget(context.Background(), h, time.Second)
+
+		if got := h.Get(BudgetHeader); got != "" {
+			t.Errorf("expected no budget, got '%s' instead", got)
+		}
+	})
+}
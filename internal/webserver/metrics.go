@@ -0,0 +1,79 @@
+package webserver
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// unmatchedRoute is the http.route recorded for requests no Route served,
+// so unknown paths do not each create a new series.
+const unmatchedRoute = "unmatched"
+
+type ctxKeyRoute int
+
+const routeKey ctxKeyRoute = 0
+
+// setRoute tells WithMetrics which route served the request of ctx.
+func setRoute(ctx context.Context, route string) {
+	if holder, ok := ctx.Value(routeKey).(*string); ok {
+		*holder = route
+	}
+}
+
+// WithMetrics records the request count, latency and server errors of
+// every request handled by next, by the route set by Route.
+func WithMetrics(meter metric.Meter, next http.Handler) http.Handler {
+	requests, err := meter.Int64Counter(
+		"http.server.request.count",
+		metric.WithDescription("Number of HTTP requests handled by the server."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	duration, err := meter.Float64Histogram(
+		"http.server.request.duration",
+		metric.WithDescription("Duration of HTTP requests handled by the server."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	serverErrors, err := meter.Int64Counter(
+		"http.server.request.errors",
+		metric.WithDescription("Number of HTTP requests answered with a server error."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		route := unmatchedRoute
+		r = r.WithContext(context.WithValue(r.Context(), routeKey, &route))
+
+		rw := &responseWriterWrapper{w, http.StatusOK}
+		next.ServeHTTP(rw, r)
+
+		attrs := metric.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", rw.statusCode),
+		)
+		ctx := r.Context()
+		requests.Add(ctx, 1, attrs)
+		duration.Record(ctx, time.Since(start).Seconds(), attrs)
+		if rw.statusCode >= http.StatusInternalServerError {
+			serverErrors.Add(ctx, 1, attrs)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package webserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// recordedRoutes returns the http.route of every request count series.
+func recordedRoutes(t *testing.T, reader *sdkmetric.ManualReader) []string {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("could not collect metrics: %v", err)
+	}
+
+	var routes []string
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "http.server.request.count" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				route, _ := dp.Attributes.Value("http.route")
+				routes = append(routes, route.AsString())
+			}
+		}
+	}
+	slices.Sort(routes)
+	return routes
+}
+
+func TestWithMetrics(t *testing.T) {
+	t.Run("WithMetrics should record the matched route and group unmatched paths", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+		mux := http.NewServeMux()
+		mux.Handle("GET /items/{id}", Route("GET /items/{id}", http.NotFoundHandler()))
+		sut := WithMetrics(meter, mux)
+
+		for _, path := range []string{"/items/1", "/items/2", "/unknown/1", "/unknown/2"} {
+			sut.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+
+		got := recordedRoutes(t, reader)
+		want := []string{"/items/{id}", unmatchedRoute}
+		if !slices.Equal(got, want) {
+			t.Errorf("expected routes %v, got %v instead", want, got)
+		}
+	})
+}
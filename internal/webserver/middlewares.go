@@ -5,7 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"strings"
@@ -72,7 +72,7 @@ func (w *responseWriterWrapper) WriteHeader(code int) {
 	w.ResponseWriter.WriteHeader(code)
 }
 
-func WithLogging(logger *log.Logger, next http.Handler) http.Handler {
+func WithLogging(logger *slog.Logger, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
@@ -81,16 +81,18 @@ func WithLogging(logger *log.Logger, next http.Handler) http.Handler {
 
 		elapsed := time.Since(start)
 
-		requestID := GetRequestID(r.Context())
-		if requestID != "" {
-			requestID = fmt.Sprintf("[%s] ", requestID)
-		}
-
 		scheme := "http"
 		if r.TLS != nil {
 			scheme = "https"
 		}
-		// [888d26e39339/tKgftQxacB-000039] "GET http://goapp:8080/metrics HTTP/1.1" from 172.18.0.3:41954 - 200 1395B in 2.76975ms
-		logger.Printf("%s\"%s %s://%s%s %s\" from %s - %d in %s", requestID, r.Method, scheme, r.Host, r.URL.String(), r.Proto, r.RemoteAddr, rw.statusCode, elapsed.String())
+		// {"msg":"request completed","method":"GET","url":"http://goapp:8080/ready","status":200,"duration":2769750,"request_id":"888d26e39339/tKgftQxacB-000039",...}
+		logger.LogAttrs(r.Context(), slog.LevelInfo, "request completed",
+			slog.String("method", r.Method),
+			slog.String("url", fmt.Sprintf("%s://%s%s", scheme, r.Host, r.URL.String())),
+			slog.String("proto", r.Proto),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.Int("status", rw.statusCode),
+			slog.Duration("duration", elapsed),
+		)
 	})
 }
@@ -0,0 +1,73 @@
+package webserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ProblemContentType is the media type of problem details responses.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an error response following RFC 7807. Code is a stable,
+// machine-readable identifier of the kind of problem and TraceID links
+// the response to the trace of the failed request.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	TraceID  string `json:"trace_id,omitempty"`
+}
+
+// ProblemType describes a kind of problem the services answer with.
+type ProblemType struct {
+	Code   string
+	Status int
+	Title  string
+}
+
+var (
+	ProblemInvalidInput            = ProblemType{Code: "invalid_input", Status: http.StatusBadRequest, Title: "Invalid input format"}
+	ProblemInvalidZipcode          = ProblemType{Code: "invalid_zipcode", Status: http.StatusUnprocessableEntity, Title: "Invalid zipcode"}
+	ProblemZipcodeNotFound         = ProblemType{Code: "zipcode_not_found", Status: http.StatusNotFound, Title: "Zipcode not found"}
+	ProblemCEPUnavailable          = ProblemType{Code: "cep_unavailable", Status: http.StatusBadGateway, Title: "CEP service unavailable"}
+	ProblemWeatherUnavailable      = ProblemType{Code: "weather_unavailable", Status: http.StatusBadGateway, Title: "Weather service unavailable"}
+	ProblemInvalidLocation         = ProblemType{Code: "invalid_location", Status: http.StatusUnprocessableEntity, Title: "Invalid location"}
+	ProblemOrchestratorUnavailable = ProblemType{Code: "orchestrator_unavailable", Status: http.StatusBadGateway, Title: "Orchestrator service unavailable"}
+	ProblemUnauthorized            = ProblemType{Code: "unauthorized", Status: http.StatusUnauthorized, Title: "Unauthorized"}
+	ProblemForbidden               = ProblemType{Code: "forbidden", Status: http.StatusForbidden, Title: "Forbidden"}
+	ProblemRateLimited             = ProblemType{Code: "rate_limited", Status: http.StatusTooManyRequests, Title: "Too many requests"}
+	ProblemDeadlineExceeded        = ProblemType{Code: "deadline_exceeded", Status: http.StatusGatewayTimeout, Title: "Deadline exceeded"}
+	ProblemInternal                = ProblemType{Code: "internal_error", Status: http.StatusInternalServerError, Title: "Internal server error"}
+)
+
+// NewProblem describes an occurrence of pt for the request r.
+func NewProblem(r *http.Request, pt ProblemType, detail string) Problem {
+	p := Problem{
+		Type:     "/problems/" + pt.Code,
+		Title:    pt.Title,
+		Status:   pt.Status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     pt.Code,
+	}
+	if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+		p.TraceID = sc.TraceID().String()
+	}
+	return p
+}
+
+// EncodeProblem writes an occurrence of pt as application/problem+json.
+func EncodeProblem(w http.ResponseWriter, r *http.Request, pt ProblemType, detail string) error {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(pt.Status)
+	if err := json.NewEncoder(w).Encode(NewProblem(r, pt, detail)); err != nil {
+		return fmt.Errorf("encode problem: %w", err)
+	}
+	return nil
+}
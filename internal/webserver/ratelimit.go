@@ -0,0 +1,247 @@
+package webserver
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RateLimit allows Requests per Period on average, with bursts of up to
+// Burst requests. A zero Burst means Requests.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// ParseRateLimit reads a limit written as "<requests>/<period>", with an
+// optional burst as in "60/1m,burst=10".
+func ParseRateLimit(v string) (RateLimit, error) {
+	spec, burst, hasBurst := strings.Cut(v, ",")
+	requests, period, ok := strings.Cut(spec, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: expected <requests>/<period>", v)
+	}
+
+	var limit RateLimit
+	var err error
+	if limit.Requests, err = strconv.Atoi(strings.TrimSpace(requests)); err != nil || limit.Requests <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive number", v)
+	}
+	if limit.Period, err = time.ParseDuration(strings.TrimSpace(period)); err != nil || limit.Period <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", v)
+	}
+	if hasBurst {
+		n, ok := strings.CutPrefix(strings.TrimSpace(burst), "burst=")
+		if limit.Burst, err = strconv.Atoi(n); !ok || err != nil || limit.Burst <= 0 {
+			return RateLimit{}, fmt.Errorf("invalid rate limit %q: burst must be a positive number", v)
+		}
+	}
+	return limit, nil
+}
+
+func (l RateLimit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+// rate is the number of tokens added back per second.
+func (l RateLimit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// RateLimitResult is the outcome of taking a token from a bucket.
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next token, zero when allowed.
+	RetryAfter time.Duration
+}
+
+// RateLimitStore keeps the token buckets. MemoryRateLimitStore is enough
+// for a single replica; replicas sharing a quota need a store backed by
+// a shared database implementing the same interface.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
+
+// memoryStoreMaxBuckets bounds the buckets kept in memory. Once reached,
+// the least recently used bucket is dropped, so a flood of new clients
+// can not grow the store without limit.
+const memoryStoreMaxBuckets = 100_000
+
+// MemoryRateLimitStore keeps the token buckets in memory.
+type MemoryRateLimitStore struct {
+	now        func() time.Time
+	maxBuckets int
+
+	mu      sync.Mutex
+	lru     *list.List
+	buckets map[string]*list.Element
+}
+
+type tokenBucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+var _ RateLimitStore = &MemoryRateLimitStore{}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		now:        time.Now,
+		maxBuckets: memoryStoreMaxBuckets,
+		lru:        list.New(),
+		buckets:    make(map[string]*list.Element),
+	}
+}
+
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var b *tokenBucket
+	if elem, ok := s.buckets[key]; ok {
+		s.lru.MoveToFront(elem)
+		b = elem.Value.(*tokenBucket)
+	} else {
+		if s.lru.Len() >= s.maxBuckets {
+			oldest := s.lru.Back()
+			s.lru.Remove(oldest)
+			delete(s.buckets, oldest.Value.(*tokenBucket).key)
+		}
+		b = &tokenBucket{key: key, tokens: limit.capacity(), last: now}
+		s.buckets[key] = s.lru.PushFront(b)
+	}
+	b.refill(now, limit)
+
+	res := RateLimitResult{Allowed: b.tokens >= 1}
+	if res.Allowed {
+		b.tokens--
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / limit.rate())
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((limit.capacity() - b.tokens) / limit.rate())
+	return res, nil
+}
+
+func (b *tokenBucket) refill(now time.Time, limit RateLimit) {
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(limit.capacity(), b.tokens+elapsed*limit.rate())
+	b.last = now
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// RateLimitKeyFunc picks the bucket a request is counted against.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// RateLimitByIP counts requests against the address of the connection.
+// Forwarding headers are ignored since any client can set them.
+func RateLimitByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// RateLimitByClient counts requests against the client authenticated by
+// an Authenticator, falling back to the client IP. The limiter must run
+// after the authentication for the client to be known.
+func RateLimitByClient(r *http.Request) string {
+	if identity, ok := GetIdentity(r.Context()); ok {
+		return "client:" + identity.Client
+	}
+	return RateLimitByIP(r)
+}
+
+// RateLimiter enforces token bucket limits per route and client.
+type RateLimiter struct {
+	store  RateLimitStore
+	key    RateLimitKeyFunc
+	def    RateLimit
+	routes map[string]RateLimit
+}
+
+// NewRateLimiter limits every route to def, unless routes holds a limit
+// for its pattern. A zero def leaves the other routes unlimited.
+func NewRateLimiter(store RateLimitStore, key RateLimitKeyFunc, def RateLimit, routes map[string]RateLimit) *RateLimiter {
+	return &RateLimiter{
+		store:  store,
+		key:    key,
+		def:    def,
+		routes: routes,
+	}
+}
+
+// Limit wraps the handler registered for pattern. Requests over the limit
+// get a 429 problem response with a Retry-After header; every response
+// carries the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset
+// headers. Should the store fail, requests are let through. A nil
+// RateLimiter limits nothing.
+func (l *RateLimiter) Limit(pattern string, next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	limit, ok := l.routes[pattern]
+	if !ok {
+		limit = l.def
+	}
+	if limit.Requests <= 0 {
+		return next
+	}
+
+	policy := fmt.Sprintf("%d;w=%d", limit.Requests, int(math.Ceil(limit.Period.Seconds())))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		key := pattern + "|" + l.key(r)
+
+		res, err := l.store.Take(r.Context(), key, limit)
+		if err != nil {
+			span.AddEvent("ratelimit.store_error", trace.WithAttributes(attribute.String("error.message", err.Error())))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Policy", policy)
+		h.Set("RateLimit-Limit", strconv.Itoa(int(limit.capacity())))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		span.SetAttributes(
+			attribute.Bool("ratelimit.allowed", res.Allowed),
+			attribute.Int("ratelimit.remaining", res.Remaining),
+		)
+
+		if !res.Allowed {
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			_ = EncodeProblem(w, r, ProblemRateLimited, "too many requests, retry later")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
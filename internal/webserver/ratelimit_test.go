@@ -0,0 +1,209 @@
+package webserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    RateLimit
+		wantErr bool
+	}{
+		{name: "ParseRateLimit should read requests per period", value: "60/1m", want: RateLimit{Requests: 60, Period: time.Minute}},
+		{name: "ParseRateLimit should read the burst", value: "10/1s,burst=20", want: RateLimit{Requests: 10, Period: time.Second, Burst: 20}},
+		{name: "ParseRateLimit should ignore surrounding spaces", value: " 5 / 10s , burst=2", want: RateLimit{Requests: 5, Period: 10 * time.Second, Burst: 2}},
+		{name: "ParseRateLimit should require a period", value: "60", wantErr: true},
+		{name: "ParseRateLimit should reject zero requests", value: "0/1m", wantErr: true},
+		{name: "ParseRateLimit should reject malformed periods", value: "60/minute", wantErr: true},
+		{name: "ParseRateLimit should reject negative periods", value: "60/-1m", wantErr: true},
+		{name: "ParseRateLimit should reject unknown options", value: "60/1m,size=10", wantErr: true},
+		{name: "ParseRateLimit should reject zero bursts", value: "60/1m,burst=0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRateLimit(tt.value)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v instead", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil, got '%v' instead", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v instead", tt.want, got)
+			}
+		})
+	}
+}
+
+func newTestStore() (*MemoryRateLimitStore, *time.Time) {
+	sut := NewMemoryRateLimitStore()
+	now := time.Now()
+	sut.now = func() time.Time { return now }
+	return sut, &now
+}
+
+func TestMemoryRateLimitStore_Take(t *testing.T) {
+	limit := RateLimit{Requests: 2, Period: 2 * time.Second}
+
+	t.Run("MemoryRateLimitStore should refuse once the bucket is empty", func(t *testing.T) {
+		sut, _ := newTestStore()
+
+		_, _ = sut.Take(context.Background(), "key", limit)
+		_, _ = sut.Take(context.Background(), "key", limit)
+		got, _ := sut.Take(context.Background(), "key", limit)
+
+		if got.Allowed {
+			t.Errorf("expected the request to be refused")
+		}
+
+		if got.RetryAfter != time.Second {
+			t.Errorf("expected to retry after 1s, got %s instead", got.RetryAfter)
+		}
+	})
+
+	t.Run("MemoryRateLimitStore should refill tokens over time", func(t *testing.T) {
+		sut, now := newTestStore()
+
+		_, _ = sut.Take(context.Background(), "key", limit)
+		_, _ = sut.Take(context.Background(), "key", limit)
+		*now = now.Add(time.Second)
+		got, _ := sut.Take(context.Background(), "key", limit)
+
+		if !got.Allowed {
+			t.Errorf("expected the refilled token to be taken")
+		}
+
+		if got.Remaining != 0 || got.Reset != 2*time.Second {
+			t.Errorf("expected 0 remaining and a 2s reset, got %+v instead", got)
+		}
+	})
+
+	t.Run("MemoryRateLimitStore should keep separate buckets per key", func(t *testing.T) {
+		sut, _ := newTestStore()
+
+		_, _ = sut.Take(context.Background(), "a", limit)
+		_, _ = sut.Take(context.Background(), "a", limit)
+		got, _ := sut.Take(context.Background(), "b", limit)
+
+		if !got.Allowed || got.Remaining != 1 {
+			t.Errorf("expected a fresh bucket, got %+v instead", got)
+		}
+	})
+
+	t.Run("MemoryRateLimitStore should drop the least recently used bucket once full", func(t *testing.T) {
+		sut, _ := newTestStore()
+		sut.maxBuckets = 2
+
+		_, _ = sut.Take(context.Background(), "a", limit)
+		_, _ = sut.Take(context.Background(), "b", limit)
+		_, _ = sut.Take(context.Background(), "a", limit)
+		_, _ = sut.Take(context.Background(), "c", limit)
+
+		if len(sut.buckets) != 2 {
+			t.Errorf("expected 2 buckets, got %d instead", len(sut.buckets))
+		}
+
+		if _, ok := sut.buckets["b"]; ok {
+			t.Errorf("expected bucket b to be dropped")
+		}
+	})
+}
+
+func TestRateLimiter_Limit(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	limit := RateLimit{Requests: 1, Period: time.Minute}
+
+	t.Run("RateLimiter should announce the limit on every response", func(t *testing.T) {
+		store, _ := newTestStore()
+		sut := NewRateLimiter(store, RateLimitByIP, limit, nil).Limit("GET /", ok)
+		w := httptest.NewRecorder()
+
+		sut.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		want := map[string]string{
+			"RateLimit-Policy":    "1;w=60",
+			"RateLimit-Limit":     "1",
+			"RateLimit-Remaining": "0",
+			"RateLimit-Reset":     "60",
+		}
+		for header, value := range want {
+			if got := w.Header().Get(header); got != value {
+				t.Errorf("expected %s to be '%s', got '%s' instead", header, value, got)
+			}
+		}
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("expected status %d, got %d instead", http.StatusNoContent, w.Code)
+		}
+	})
+
+	t.Run("RateLimiter should answer 429 with Retry-After once over the limit", func(t *testing.T) {
+		store, _ := newTestStore()
+		sut := NewRateLimiter(store, RateLimitByIP, limit, nil).Limit("GET /", ok)
+		sut.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		w := httptest.NewRecorder()
+
+		sut.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if w.Code != http.StatusTooManyRequests {
+			t.Errorf("expected status %d, got %d instead", http.StatusTooManyRequests, w.Code)
+		}
+
+		if got := w.Header().Get("Retry-After"); got != "60" {
+			t.Errorf("expected Retry-After to be '60', got '%s' instead", got)
+		}
+
+		if got := w.Header().Get("Content-Type"); got != ProblemContentType {
+			t.Errorf("expected a problem response, got '%s' instead", got)
+		}
+	})
+
+	t.Run("RateLimiter should count clients separately", func(t *testing.T) {
+		store, _ := newTestStore()
+		sut := NewRateLimiter(store, RateLimitByIP, limit, nil).Limit("GET /", ok)
+		first := httptest.NewRequest(http.MethodGet, "/", nil)
+		first.RemoteAddr = "10.0.0.1:1234"
+		second := httptest.NewRequest(http.MethodGet, "/", nil)
+		second.RemoteAddr = "10.0.0.2:1234"
+		sut.ServeHTTP(httptest.NewRecorder(), first)
+		w := httptest.NewRecorder()
+
+		sut.ServeHTTP(w, second)
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("expected status %d, got %d instead", http.StatusNoContent, w.Code)
+		}
+	})
+
+	t.Run("RateLimiter should leave routes with an empty limit alone", func(t *testing.T) {
+		store, _ := newTestStore()
+		sut := NewRateLimiter(store, RateLimitByIP, limit, map[string]RateLimit{"GET /ready": {}}).Limit("GET /ready", ok)
+		sut.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ready", nil))
+		w := httptest.NewRecorder()
+
+		sut.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("expected status %d, got %d instead", http.StatusNoContent, w.Code)
+		}
+
+		if got := w.Header().Get("RateLimit-Limit"); got != "" {
+			t.Errorf("expected no RateLimit-Limit header, got '%s' instead", got)
+		}
+	})
+}
@@ -1,5 +1,21 @@
 package webserver
 
-type ErrorResponse struct {
-	Message string `json:"Message"`
+// TelemetryStatus reports whether the telemetry pipeline is degraded.
+type TelemetryStatus interface {
+	Degraded() bool
+}
+
+type ReadyResponse struct {
+	Status    string `json:"status"`
+	Telemetry string `json:"telemetry"`
+}
+
+// NewReadyResponse describes a ready service, flagging telemetry as
+// degraded without failing the readiness check.
+func NewReadyResponse(telemetry TelemetryStatus) ReadyResponse {
+	res := ReadyResponse{Status: "ok", Telemetry: "ok"}
+	if telemetry != nil && telemetry.Degraded() {
+		res.Telemetry = "degraded"
+	}
+	return res
 }
@@ -0,0 +1,82 @@
+package webserver
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracing starts a server span for every request, continuing the
+// trace propagated by the caller. The span is named after the method
+// alone until Route names it after the matched pattern, so unmatched
+// paths do not create new span names.
+//
+// otelhttp records the older HTTP semantic conventions unless the process
+// opts in through the environment, so the stable attributes are recorded
+// here as well.
+func WithTracing(operation string, next http.Handler) http.Handler {
+	return otelhttp.NewHandler(withServerAttributes(next), operation,
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method
+		}),
+	)
+}
+
+func withServerAttributes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		attrs := []attribute.KeyValue{
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.URLScheme(scheme),
+			semconv.URLPath(r.URL.Path),
+		}
+		host, port, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		attrs = append(attrs, semconv.ServerAddress(host))
+		if p, err := strconv.Atoi(port); err == nil {
+			attrs = append(attrs, semconv.ServerPort(p))
+		}
+		span := trace.SpanFromContext(r.Context())
+		span.SetAttributes(attrs...)
+
+		rw := &responseWriterWrapper{w, http.StatusOK}
+		next.ServeHTTP(rw, r)
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rw.statusCode))
+	})
+}
+
+// Route wraps the handler registered for pattern, naming the request span
+// after the pattern and recording its path as http.route, on the span and
+// on the WithMetrics series.
+func Route(pattern string, next http.Handler) http.Handler {
+	route := routeOf(pattern)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(attribute.String("http.route", route))
+		setRoute(r.Context(), route)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// routeOf strips the method and host from a ServeMux pattern.
+func routeOf(pattern string) string {
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		pattern = strings.TrimSpace(path)
+	}
+	if i := strings.Index(pattern, "/"); i > 0 {
+		pattern = pattern[i:]
+	}
+	return pattern
+}
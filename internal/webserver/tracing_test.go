@@ -0,0 +1,66 @@
+package webserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /api/weather", Route("POST /api/weather", http.NotFoundHandler()))
+	mux.Handle("/items/{id}", Route("/items/{id}", http.NotFoundHandler()))
+	sut := WithTracing("test", mux)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   string
+	}{
+		{name: "WithTracing should name spans after the matched pattern", method: http.MethodPost, path: "/api/weather", want: "POST /api/weather"},
+		{name: "WithTracing should name spans after patterns without a method", method: http.MethodGet, path: "/items/42", want: "GET /items/{id}"},
+		{name: "WithTracing should name unmatched requests after the method alone", method: http.MethodGet, path: "/api/weather/anything", want: "GET"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sut.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			spans := recorder.Ended()
+			if got := spans[len(spans)-1].Name(); got != tt.want {
+				t.Errorf("expected span name '%s', got '%s' instead", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("WithTracing should record the stable HTTP attributes on the server span", func(t *testing.T) {
+		sut.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "http://example.com:8080/api/weather", nil))
+
+		spans := recorder.Ended()
+		attrs := attribute.NewSet(spans[len(spans)-1].Attributes()...)
+		want := []attribute.KeyValue{
+			attribute.String("http.request.method", "POST"),
+			attribute.Int("http.response.status_code", http.StatusNotFound),
+			attribute.String("http.route", "/api/weather"),
+			attribute.String("server.address", "example.com"),
+			attribute.Int("server.port", 8080),
+			attribute.String("url.path", "/api/weather"),
+		}
+		for _, kv := range want {
+			if got, ok := attrs.Value(kv.Key); !ok || got != kv.Value {
+				t.Errorf("expected %s to be '%s', got '%s' instead", kv.Key, kv.Value.Emit(), got.Emit())
+			}
+		}
+	})
+}
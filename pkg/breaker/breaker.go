@@ -0,0 +1,228 @@
+// Package breaker implements a circuit breaker that stops calling an
+// upstream once too many of its recent calls failed, letting it recover
+// before probing it again.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrOpen is returned by Allow while the breaker rejects calls.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the position of a breaker.
+type State int
+
+const (
+	// Closed lets every call through while counting failures.
+	Closed State = iota
+	// HalfOpen lets a few probe calls through to test the upstream.
+	HalfOpen
+	// Open rejects every call until the open timeout passes.
+	Open
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case HalfOpen:
+		return "half-open"
+	case Open:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// Settings tunes when a breaker trips and how it recovers.
+type Settings struct {
+	// Window is how long failures are counted for while closed.
+	Window time.Duration
+	// MinRequests is the number of calls in the window needed before
+	// the failure ratio is taken into account.
+	MinRequests int
+	// FailureRatio trips the breaker once that share of the calls in the
+	// window failed.
+	FailureRatio float64
+	// OpenTimeout is how long the breaker stays open before probing.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of successful probes needed to
+	// close the breaker again.
+	HalfOpenRequests int
+	// OnStateChange, when set, is called after every transition. It runs
+	// while the breaker is locked and must not call it back.
+	OnStateChange func(name string, from, to State)
+}
+
+// DefaultSettings trips after half of at least five calls failed within
+// 30s, and probes again after 15s.
+func DefaultSettings() Settings {
+	return Settings{
+		Window:           30 * time.Second,
+		MinRequests:      5,
+		FailureRatio:     0.5,
+		OpenTimeout:      15 * time.Second,
+		HalfOpenRequests: 1,
+	}
+}
+
+// Counts are the calls seen in the current window or probe round.
+type Counts struct {
+	Requests  int `json:"requests"`
+	Failures  int `json:"failures"`
+	Successes int `json:"successes"`
+}
+
+// Breaker is a circuit breaker guarding a single upstream.
+type Breaker struct {
+	name     string
+	settings Settings
+	now      func() time.Time
+
+	mu          sync.Mutex
+	state       State
+	generation  uint64
+	counts      Counts
+	windowStart time.Time
+	openedAt    time.Time
+	inFlight    int
+}
+
+func New(name string, settings Settings) *Breaker {
+	if settings.HalfOpenRequests <= 0 {
+		settings.HalfOpenRequests = 1
+	}
+	b := &Breaker{name: name, settings: settings, now: time.Now}
+	b.windowStart = b.now()
+	return b
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State returns the current state, moving an open breaker whose timeout
+// has passed to half-open.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(context.Background(), b.now())
+	return b.state
+}
+
+// Counts returns the calls seen in the current window or probe round.
+func (b *Breaker) Counts() Counts {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.counts
+}
+
+// Allow reports whether a call may go through. When it may, the caller
+// must report its outcome through done: a nil error is a success and
+// anything else is a failure, unless ctx was already done by then. A call
+// cut short by its caller, whether cancelled or out of time, says nothing
+// about the provider and is ignored.
+// Transitions are recorded as "breaker.state_change" events on the span
+// of ctx.
+func (b *Breaker) Allow(ctx context.Context) (done func(err error), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(ctx, b.now())
+	switch b.state {
+	case Open:
+		return nil, ErrOpen
+	case HalfOpen:
+		if b.inFlight+b.counts.Successes >= b.settings.HalfOpenRequests {
+			return nil, ErrOpen
+		}
+	}
+
+	b.inFlight++
+	b.counts.Requests++
+	generation := b.generation
+	return func(err error) {
+		b.done(ctx, generation, err)
+	}, nil
+}
+
+func (b *Breaker) done(ctx context.Context, generation uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.advance(ctx, now)
+	if generation != b.generation {
+		return
+	}
+	b.inFlight--
+
+	switch {
+	case ctx.Err() != nil, errors.Is(err, context.Canceled):
+		b.counts.Requests--
+	case err != nil:
+		b.counts.Failures++
+		if b.state == HalfOpen || b.shouldTrip() {
+			b.setState(ctx, Open, now)
+		}
+	default:
+		b.counts.Successes++
+		if b.state == HalfOpen && b.counts.Successes >= b.settings.HalfOpenRequests {
+			b.setState(ctx, Closed, now)
+		}
+	}
+}
+
+func (b *Breaker) shouldTrip() bool {
+	return b.counts.Requests >= b.settings.MinRequests &&
+		float64(b.counts.Failures) >= b.settings.FailureRatio*float64(b.counts.Requests)
+}
+
+// advance applies the transitions that only depend on time: the closed
+// window rolling over and the open timeout expiring.
+func (b *Breaker) advance(ctx context.Context, now time.Time) {
+	switch b.state {
+	case Closed:
+		if b.settings.Window > 0 && !now.Before(b.windowStart.Add(b.settings.Window)) {
+			b.reset(now)
+		}
+	case Open:
+		if !now.Before(b.openedAt.Add(b.settings.OpenTimeout)) {
+			b.setState(ctx, HalfOpen, now)
+		}
+	}
+}
+
+func (b *Breaker) setState(ctx context.Context, to State, now time.Time) {
+	from := b.state
+	b.state = to
+	if to == Open {
+		b.openedAt = now
+	}
+	b.reset(now)
+
+	trace.SpanFromContext(ctx).AddEvent("breaker.state_change", trace.WithAttributes(
+		attribute.String("breaker.name", b.name),
+		attribute.String("breaker.from", from.String()),
+		attribute.String("breaker.to", to.String()),
+	))
+	if b.settings.OnStateChange != nil {
+		b.settings.OnStateChange(b.name, from, to)
+	}
+}
+
+func (b *Breaker) reset(now time.Time) {
+	b.generation++
+	b.counts = Counts{}
+	b.inFlight = 0
+	b.windowStart = now
+}
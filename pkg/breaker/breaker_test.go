@@ -0,0 +1,162 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errUpstream = errors.New("upstream failed")
+
+func newTestBreaker() (*Breaker, *time.Time) {
+	sut := New("test", Settings{
+		Window:           time.Minute,
+		MinRequests:      4,
+		FailureRatio:     0.5,
+		OpenTimeout:      10 * time.Second,
+		HalfOpenRequests: 1,
+	})
+	now := time.Now()
+	sut.now = func() time.Time { return now }
+	sut.windowStart = now
+	return sut, &now
+}
+
+func call(b *Breaker, err error) error {
+	done, allowErr := b.Allow(context.Background())
+	if allowErr != nil {
+		return allowErr
+	}
+	done(err)
+	return nil
+}
+
+func TestBreaker(t *testing.T) {
+	t.Run("Breaker should open once the failure ratio is reached", func(t *testing.T) {
+		sut, _ := newTestBreaker()
+
+		_ = call(sut, nil)
+		_ = call(sut, errUpstream)
+		_ = call(sut, nil)
+
+		if sut.State() != Closed {
+			t.Errorf("expected breaker to stay closed below the min requests, got %s instead", sut.State())
+		}
+
+		_ = call(sut, errUpstream)
+
+		if sut.State() != Open {
+			t.Errorf("expected breaker to be open, got %s instead", sut.State())
+		}
+
+		if err := call(sut, nil); !errors.Is(err, ErrOpen) {
+			t.Errorf("expected open breaker error, got '%v' instead", err)
+		}
+	})
+
+	t.Run("Breaker should forget failures once the window rolls over", func(t *testing.T) {
+		sut, now := newTestBreaker()
+
+		_ = call(sut, errUpstream)
+		_ = call(sut, errUpstream)
+		_ = call(sut, errUpstream)
+		*now = now.Add(time.Minute)
+		_ = call(sut, errUpstream)
+
+		if sut.State() != Closed {
+			t.Errorf("expected breaker to be closed, got %s instead", sut.State())
+		}
+	})
+
+	t.Run("Breaker should close after a successful probe", func(t *testing.T) {
+		sut, now := newTestBreaker()
+		for range 4 {
+			_ = call(sut, errUpstream)
+		}
+
+		*now = now.Add(10 * time.Second)
+
+		if sut.State() != HalfOpen {
+			t.Fatalf("expected breaker to be half-open, got %s instead", sut.State())
+		}
+
+		done, err := sut.Allow(context.Background())
+		if err != nil {
+			t.Fatalf("expected probe to be allowed, got '%v' instead", err)
+		}
+
+		if _, err := sut.Allow(context.Background()); !errors.Is(err, ErrOpen) {
+			t.Errorf("expected concurrent probe to be rejected, got '%v' instead", err)
+		}
+
+		done(nil)
+
+		if sut.State() != Closed {
+			t.Errorf("expected breaker to be closed, got %s instead", sut.State())
+		}
+	})
+
+	t.Run("Breaker should open again after a failed probe", func(t *testing.T) {
+		sut, now := newTestBreaker()
+		for range 4 {
+			_ = call(sut, errUpstream)
+		}
+		*now = now.Add(10 * time.Second)
+
+		_ = call(sut, errUpstream)
+
+		if sut.State() != Open {
+			t.Errorf("expected breaker to be open, got %s instead", sut.State())
+		}
+	})
+
+	t.Run("Breaker should ignore cancelled calls", func(t *testing.T) {
+		sut, _ := newTestBreaker()
+
+		for range 4 {
+			_ = call(sut, context.Canceled)
+		}
+
+		if got := sut.Counts(); got.Requests != 0 || got.Failures != 0 {
+			t.Errorf("expected cancelled calls not to be counted, got %+v instead", got)
+		}
+	})
+
+	t.Run("Breaker should ignore calls whose deadline ran out", func(t *testing.T) {
+		sut, _ := newTestBreaker()
+		ctx, cancel := context.WithTimeout(context.Background(), 0)
+		defer cancel()
+
+		for range 4 {
+			done, err := sut.Allow(ctx)
+			if err != nil {
+				t.Fatalf("expected call to be allowed, got '%v' instead", err)
+			}
+			done(context.DeadlineExceeded)
+		}
+
+		if got := sut.Counts(); got.Requests != 0 || got.Failures != 0 {
+			t.Errorf("expected expired calls not to be counted, got %+v instead", got)
+		}
+
+		if sut.State() != Closed {
+			t.Errorf("expected breaker to stay closed, got %s instead", sut.State())
+		}
+	})
+
+	t.Run("Breaker should report transitions", func(t *testing.T) {
+		var transitions []string
+		sut := New("provider", Settings{MinRequests: 1, FailureRatio: 1, OpenTimeout: time.Minute,
+			OnStateChange: func(name string, from, to State) {
+				transitions = append(transitions, name+":"+from.String()+"->"+to.String())
+			},
+		})
+
+		_ = call(sut, errUpstream)
+
+		if len(transitions) != 1 || transitions[0] != "provider:closed->open" {
+			t.Errorf("expected closed->open transition, got %v instead", transitions)
+		}
+	})
+}
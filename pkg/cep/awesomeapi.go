@@ -2,13 +2,16 @@ package cep
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/httpclient"
 )
 
+const awesomeAPIBaseURL = "https://cep.awesomeapi.com.br"
+
 type awesomeAPIResponse struct {
 	Cep       string `json:"cep"`
 	Street    string `json:"address"`
@@ -20,18 +23,29 @@ type awesomeAPIResponse struct {
 }
 
 type AwesomeAPILoader struct {
-	client *http.Client
+	baseURL string
+	client  *http.Client
 }
 
 var _ Loader = &AwesomeAPILoader{}
 
-func NewAwesomeAPILoader() *AwesomeAPILoader {
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+func NewAwesomeAPILoader(opts ...Option) (*AwesomeAPILoader, error) {
+	c, err := newConfig(awesomeAPIBaseURL, opts)
+	if err != nil {
+		return nil, err
 	}
 	return &AwesomeAPILoader{
-		client: &http.Client{Transport: tr},
-	}
+		baseURL: c.BaseURL,
+		client:  c.Client,
+	}, nil
+}
+
+func (l *AwesomeAPILoader) Name() string {
+	return "AwesomeAPI"
+}
+
+func (l *AwesomeAPILoader) Warmup(ctx context.Context) error {
+	return httpclient.Warmup(ctx, l.client, l.baseURL)
 }
 
 func (l *AwesomeAPILoader) Load(ctx context.Context, cep string) (CEP, error) {
@@ -39,7 +53,7 @@ func (l *AwesomeAPILoader) Load(ctx context.Context, cep string) (CEP, error) {
 		return CEP{}, ErrInvalidCEP
 	}
 
-	url := fmt.Sprintf("https://cep.awesomeapi.com.br/json/%s", cep)
+	url := fmt.Sprintf("%s/json/%s", l.baseURL, cep)
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return CEP{}, err
@@ -80,7 +94,7 @@ func (l *AwesomeAPILoader) Load(ctx context.Context, cep string) (CEP, error) {
 		State:        b.State,
 		Latitude:     b.Latitude,
 		Longitude:    b.Longitude,
-		Service:      "AwesomeAPI",
+		Service:      l.Name(),
 	}
 
 	return c, nil
@@ -8,7 +8,7 @@ import (
 
 func TestAwesomeAPILoader_Load(t *testing.T) {
 	t.Run("AwesomeAPI should return error on invalid CEP", func(t *testing.T) {
-		sut := NewAwesomeAPILoader()
+		sut, _ := NewAwesomeAPILoader()
 		ctx := context.Background()
 
 		_, err := sut.Load(ctx, "invalid-cep")
@@ -19,7 +19,7 @@ func TestAwesomeAPILoader_Load(t *testing.T) {
 	})
 
 	t.Run("AwesomeAPI should return cep not found error on non-existent cep", func(t *testing.T) {
-		sut := NewAwesomeAPILoader()
+		sut, _ := NewAwesomeAPILoader()
 		ctx := context.Background()
 
 		_, err := sut.Load(ctx, "99999999")
@@ -30,7 +30,7 @@ func TestAwesomeAPILoader_Load(t *testing.T) {
 	})
 
 	t.Run("AwesomeAPI should return address on valid cep", func(t *testing.T) {
-		sut := NewAwesomeAPILoader()
+		sut, _ := NewAwesomeAPILoader()
 		ctx := context.Background()
 
 		got, err := sut.Load(ctx, "25808110")
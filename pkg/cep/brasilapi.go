@@ -0,0 +1,121 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/httpclient"
+)
+
+const brasilAPIBaseURL = "https://brasilapi.com.br"
+
+// brasilAPICoordinate accepts coordinates served either as strings or
+// as plain JSON numbers.
+type brasilAPICoordinate string
+
+func (c *brasilAPICoordinate) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		s = ""
+	}
+	*c = brasilAPICoordinate(s)
+	return nil
+}
+
+type brasilAPIResponse struct {
+	Cep          string `json:"cep"`
+	State        string `json:"state"`
+	City         string `json:"city"`
+	Neighborhood string `json:"neighborhood"`
+	Street       string `json:"street"`
+	Location     struct {
+		Coordinates struct {
+			Latitude  brasilAPICoordinate `json:"latitude"`
+			Longitude brasilAPICoordinate `json:"longitude"`
+		} `json:"coordinates"`
+	} `json:"location"`
+}
+
+type BrasilAPILoader struct {
+	baseURL string
+	client  *http.Client
+}
+
+var _ Loader = &BrasilAPILoader{}
+
+func NewBrasilAPILoader(opts ...Option) (*BrasilAPILoader, error) {
+	c, err := newConfig(brasilAPIBaseURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &BrasilAPILoader{
+		baseURL: c.BaseURL,
+		client:  c.Client,
+	}, nil
+}
+
+func (l *BrasilAPILoader) Name() string {
+	return "BrasilAPI"
+}
+
+func (l *BrasilAPILoader) Warmup(ctx context.Context) error {
+	return httpclient.Warmup(ctx, l.client, l.baseURL)
+}
+
+func (l *BrasilAPILoader) Load(ctx context.Context, cep string) (CEP, error) {
+	if !Valid(cep) {
+		return CEP{}, ErrInvalidCEP
+	}
+
+	url := fmt.Sprintf("%s/api/cep/v2/%s", l.baseURL, cep)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return CEP{}, err
+	}
+
+	res, err := l.client.Do(req)
+	if err != nil {
+		return CEP{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 400 {
+		return CEP{}, ErrInvalidCEP
+	}
+
+	if res.StatusCode == 404 {
+		return CEP{}, ErrCEPNotFound
+	}
+
+	if res.StatusCode != 200 {
+		return CEP{}, ErrServiceUnavailable
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return CEP{}, err
+	}
+
+	var b brasilAPIResponse
+	err = json.Unmarshal(body, &b)
+	if err != nil {
+		return CEP{}, err
+	}
+
+	c := CEP{
+		Cep:          b.Cep,
+		Street:       b.Street,
+		Neighborhood: b.Neighborhood,
+		City:         b.City,
+		State:        b.State,
+		Latitude:     string(b.Location.Coordinates.Latitude),
+		Longitude:    string(b.Location.Coordinates.Longitude),
+		Service:      l.Name(),
+	}
+
+	return c, nil
+}
@@ -0,0 +1,102 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newBrasilAPIServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/cep/v2/25808110", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"cep": "25808110",
+			"state": "RJ",
+			"city": "Três Rios",
+			"neighborhood": "Centro",
+			"street": "Rua Exemplo",
+			"service": "open-cep",
+			"location": {
+				"type": "Point",
+				"coordinates": {"longitude": "-43.2116", "latitude": "-22.09967"}
+			}
+		}`))
+	})
+	mux.HandleFunc("GET /api/cep/v2/99999999", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("GET /api/cep/v2/88888888", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	mux.HandleFunc("GET /api/cep/v2/77777777", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestBrasilAPILoader_Load(t *testing.T) {
+	srv := newBrasilAPIServer(t)
+
+	t.Run("BrasilAPI should return error on invalid CEP", func(t *testing.T) {
+		sut, _ := NewBrasilAPILoader(WithBaseURL(srv.URL))
+		ctx := context.Background()
+
+		_, err := sut.Load(ctx, "invalid-cep")
+
+		if !errors.Is(err, ErrInvalidCEP) {
+			t.Errorf("expected invalid CEP error, got '%v' instead", err)
+		}
+	})
+
+	t.Run("BrasilAPI should map provider status codes to package errors", func(t *testing.T) {
+		tests := []struct {
+			cep  string
+			want error
+		}{
+			{cep: "99999999", want: ErrCEPNotFound},
+			{cep: "88888888", want: ErrInvalidCEP},
+			{cep: "77777777", want: ErrServiceUnavailable},
+		}
+		sut, _ := NewBrasilAPILoader(WithBaseURL(srv.URL), WithHTTPClient(srv.Client()))
+		ctx := context.Background()
+
+		for _, test := range tests {
+			_, err := sut.Load(ctx, test.cep)
+
+			if !errors.Is(err, test.want) {
+				t.Errorf("(%s): expected '%v', got '%v' instead", test.cep, test.want, err)
+			}
+		}
+	})
+
+	t.Run("BrasilAPI should return address with coordinates on valid cep", func(t *testing.T) {
+		sut, _ := NewBrasilAPILoader(WithBaseURL(srv.URL))
+		ctx := context.Background()
+
+		got, err := sut.Load(ctx, "25808110")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.Latitude != "-22.09967" {
+			t.Errorf("expected latitude to be -22.09967, got '%s' instead", got.Latitude)
+		}
+
+		if got.Longitude != "-43.2116" {
+			t.Errorf("expected longitude to be -43.2116, got '%s' instead", got.Longitude)
+		}
+
+		if got.Service != "BrasilAPI" {
+			t.Errorf("expected service to be BrasilAPI, got '%s' instead", got.Service)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/provider"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/breaker"
+)
+
+// BreakerLoader guards a provider with a circuit breaker. While the
+// breaker is open, lookups fail right away with ErrServiceUnavailable
+// instead of waiting for a provider known to be down. ErrCEPNotFound and
+// ErrInvalidCEP are valid answers and do not count as failures.
+type BreakerLoader struct {
+	next    Loader
+	breaker *breaker.Breaker
+}
+
+var _ Loader = &BreakerLoader{}
+
+func NewBreakerLoader(next Loader, b *breaker.Breaker) *BreakerLoader {
+	return &BreakerLoader{
+		next:    next,
+		breaker: b,
+	}
+}
+
+func (l *BreakerLoader) Name() string {
+	return provider.Name(l.next)
+}
+
+// Warmup warms up the wrapped loader.
+func (l *BreakerLoader) Warmup(ctx context.Context) error {
+	return provider.Warmup(ctx, l.next)
+}
+
+func (l *BreakerLoader) Load(ctx context.Context, cep string) (CEP, error) {
+	// A caller that already gave up must not count against the provider.
+	if err := ctx.Err(); err != nil {
+		return CEP{}, err
+	}
+
+	done, err := l.breaker.Allow(ctx)
+	if err != nil {
+		return CEP{}, fmt.Errorf("%w: %s: %w", ErrServiceUnavailable, provider.Name(l.next), err)
+	}
+
+	c, err := l.next.Load(ctx, cep)
+	if errors.Is(err, ErrCEPNotFound) || errors.Is(err, ErrInvalidCEP) {
+		done(nil)
+	} else {
+		done(err)
+	}
+	return c, err
+}
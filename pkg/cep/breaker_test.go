@@ -0,0 +1,91 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/breaker"
+)
+
+func testBreaker() *breaker.Breaker {
+	settings := breaker.DefaultSettings()
+	settings.MinRequests = 2
+	return breaker.New("test", settings)
+}
+
+func TestBreakerLoader_Load(t *testing.T) {
+	t.Run("BreakerLoader should fail fast once the provider keeps failing", func(t *testing.T) {
+		next := failingLoader("next", ErrServiceUnavailable)
+		sut := NewBreakerLoader(next, testBreaker())
+
+		_, _ = sut.Load(context.Background(), "25808110")
+		_, _ = sut.Load(context.Background(), "25808110")
+		_, err := sut.Load(context.Background(), "25808110")
+
+		if !errors.Is(err, ErrServiceUnavailable) || !errors.Is(err, breaker.ErrOpen) {
+			t.Errorf("expected open breaker error, got '%v' instead", err)
+		}
+
+		if next.calls != 2 {
+			t.Errorf("expected 2 upstream calls, got %d instead", next.calls)
+		}
+	})
+
+	t.Run("BreakerLoader should not count not found answers as failures", func(t *testing.T) {
+		next := failingLoader("next", ErrCEPNotFound)
+		sut := NewBreakerLoader(next, testBreaker())
+
+		for range 3 {
+			_, _ = sut.Load(context.Background(), "99999999")
+		}
+
+		if next.calls != 3 {
+			t.Errorf("expected 3 upstream calls, got %d instead", next.calls)
+		}
+	})
+
+	t.Run("BreakerLoader should not count calls whose deadline already ran out", func(t *testing.T) {
+		next := failingLoader("next", ErrServiceUnavailable)
+		b := testBreaker()
+		sut := NewBreakerLoader(next, b)
+		ctx, cancel := context.WithTimeout(context.Background(), 0)
+		defer cancel()
+
+		for range 3 {
+			_, _ = sut.Load(ctx, "25808110")
+		}
+
+		if next.calls != 0 {
+			t.Errorf("expected no upstream calls, got %d instead", next.calls)
+		}
+
+		if b.State() != breaker.Closed {
+			t.Errorf("expected breaker to stay closed, got %s instead", b.State())
+		}
+	})
+
+	t.Run("BreakerLoader should let the fallback skip an open provider", func(t *testing.T) {
+		down := failingLoader("down", ErrServiceUnavailable)
+		guarded := NewBreakerLoader(down, testBreaker())
+		_, _ = guarded.Load(context.Background(), "25808110")
+		_, _ = guarded.Load(context.Background(), "25808110")
+		sut := NewFallbackLoader(noop.NewTracerProvider().Tracer("test"), guarded, succeedingLoader("up"))
+
+		got, err := sut.Load(context.Background(), "25808110")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.City != "Três Rios" {
+			t.Errorf("expected city to be Três Rios, got '%s' instead", got.City)
+		}
+
+		if down.calls != 2 {
+			t.Errorf("expected open provider not to be called, got %d calls instead", down.calls)
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package cep
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/provider"
+)
+
+// cacheSharedLoadTimeout bounds the call shared by concurrent misses,
+// which no longer belongs to any single request deadline.
+const cacheSharedLoadTimeout = 10 * time.Second
+
+// CachedLoader keeps the most recently used CEPs in memory. Successful
+// lookups live for ttl, while ErrCEPNotFound answers are kept for the
+// shorter negativeTTL. Concurrent misses for the same CEP share a single
+// call to the wrapped loader.
+type CachedLoader struct {
+	next        Loader
+	size        int
+	ttl         time.Duration
+	negativeTTL time.Duration
+	loadTimeout time.Duration
+	now         func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	group   singleflight.Group
+}
+
+type cacheEntry struct {
+	key       string
+	cep       CEP
+	err       error
+	expiresAt time.Time
+}
+
+var _ Loader = &CachedLoader{}
+
+func NewCachedLoader(next Loader, size int, ttl, negativeTTL time.Duration) *CachedLoader {
+	return &CachedLoader{
+		next:        next,
+		size:        size,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		loadTimeout: cacheSharedLoadTimeout,
+		now:         time.Now,
+		lru:         list.New(),
+		entries:     make(map[string]*list.Element),
+	}
+}
+
+// Warmup warms up the wrapped loader.
+func (l *CachedLoader) Warmup(ctx context.Context) error {
+	return provider.Warmup(ctx, l.next)
+}
+
+func (l *CachedLoader) Load(ctx context.Context, cep string) (CEP, error) {
+	if !Valid(cep) {
+		return CEP{}, ErrInvalidCEP
+	}
+
+	span := trace.SpanFromContext(ctx)
+	if entry, ok := l.get(cep); ok {
+		span.SetAttributes(attribute.Bool("cep.cache.hit", true))
+		return entry.cep, entry.err
+	}
+	span.SetAttributes(attribute.Bool("cep.cache.hit", false))
+
+	// The shared call must outlive the caller that started it, since
+	// other callers may be waiting on the same result, but not a provider
+	// that never answers.
+	loadCtx := context.WithoutCancel(ctx)
+	ch := l.group.DoChan(cep, func() (any, error) {
+		ctx, cancel := context.WithTimeout(loadCtx, l.loadTimeout)
+		defer cancel()
+
+		c, err := l.next.Load(ctx, cep)
+		l.set(cep, c, err)
+		return c, err
+	})
+
+	select {
+	case <-ctx.Done():
+		return CEP{}, ctx.Err()
+	case res := <-ch:
+		span.SetAttributes(attribute.Bool("cep.cache.shared", res.Shared))
+		return res.Val.(CEP), res.Err
+	}
+}
+
+// Peek returns the last known address of cep without calling the wrapped
+// loader, even when its entry has already expired. Coordinates rarely
+// change, so callers may use it to start dependent work early and confirm
+// it once Load answers.
+func (l *CachedLoader) Peek(cep string) (CEP, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.entries[cep]
+	if !ok {
+		return CEP{}, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if entry.err != nil {
+		return CEP{}, false
+	}
+	return entry.cep, true
+}
+
+func (l *CachedLoader) get(key string) (*cacheEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if !l.now().Before(entry.expiresAt) {
+		return nil, false
+	}
+
+	l.lru.MoveToFront(elem)
+	return entry, true
+}
+
+func (l *CachedLoader) set(key string, c CEP, err error) {
+	ttl := l.ttl
+	if err != nil {
+		if !errors.Is(err, ErrCEPNotFound) {
+			return
+		}
+		ttl = l.negativeTTL
+		err = ErrCEPNotFound
+	}
+	if ttl <= 0 || l.size <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry := &cacheEntry{key: key, cep: c, err: err, expiresAt: l.now().Add(ttl)}
+	if elem, ok := l.entries[key]; ok {
+		elem.Value = entry
+		l.lru.MoveToFront(elem)
+		return
+	}
+
+	l.entries[key] = l.lru.PushFront(entry)
+	for l.lru.Len() > l.size {
+		oldest := l.lru.Back()
+		l.lru.Remove(oldest)
+		delete(l.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
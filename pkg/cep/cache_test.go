@@ -0,0 +1,199 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCachedLoader_Load(t *testing.T) {
+	t.Run("CachedLoader should serve repeated lookups from memory until the ttl expires", func(t *testing.T) {
+		next := succeedingLoader("next")
+		sut := NewCachedLoader(next, 10, time.Hour, time.Minute)
+		now := time.Now()
+		sut.now = func() time.Time { return now }
+		ctx := context.Background()
+
+		_, _ = sut.Load(ctx, "25808110")
+		got, err := sut.Load(ctx, "25808110")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.City != "Três Rios" {
+			t.Errorf("expected city to be Três Rios, got '%s' instead", got.City)
+		}
+
+		if next.calls != 1 {
+			t.Errorf("expected 1 upstream call, got %d instead", next.calls)
+		}
+
+		now = now.Add(time.Hour)
+		_, _ = sut.Load(ctx, "25808110")
+
+		if next.calls != 2 {
+			t.Errorf("expected expired entry to be reloaded, got %d upstream calls instead", next.calls)
+		}
+	})
+
+	t.Run("CachedLoader should cache not found answers for the negative ttl", func(t *testing.T) {
+		next := failingLoader("next", ErrCEPNotFound)
+		sut := NewCachedLoader(next, 10, time.Hour, time.Minute)
+		now := time.Now()
+		sut.now = func() time.Time { return now }
+		ctx := context.Background()
+
+		_, _ = sut.Load(ctx, "99999999")
+		_, err := sut.Load(ctx, "99999999")
+
+		if !errors.Is(err, ErrCEPNotFound) {
+			t.Errorf("expected CEP not found error, got '%v' instead", err)
+		}
+
+		if next.calls != 1 {
+			t.Errorf("expected 1 upstream call, got %d instead", next.calls)
+		}
+
+		now = now.Add(time.Minute)
+		_, _ = sut.Load(ctx, "99999999")
+
+		if next.calls != 2 {
+			t.Errorf("expected negative entry to expire, got %d upstream calls instead", next.calls)
+		}
+	})
+
+	t.Run("CachedLoader should not cache unavailable providers", func(t *testing.T) {
+		next := failingLoader("next", ErrServiceUnavailable)
+		sut := NewCachedLoader(next, 10, time.Hour, time.Minute)
+		ctx := context.Background()
+
+		_, _ = sut.Load(ctx, "25808110")
+		_, _ = sut.Load(ctx, "25808110")
+
+		if next.calls != 2 {
+			t.Errorf("expected 2 upstream calls, got %d instead", next.calls)
+		}
+	})
+
+	t.Run("CachedLoader should evict the least recently used entry", func(t *testing.T) {
+		next := succeedingLoader("next")
+		sut := NewCachedLoader(next, 2, time.Hour, time.Minute)
+		ctx := context.Background()
+
+		_, _ = sut.Load(ctx, "11111111")
+		_, _ = sut.Load(ctx, "22222222")
+		_, _ = sut.Load(ctx, "11111111")
+		_, _ = sut.Load(ctx, "33333333")
+		_, _ = sut.Load(ctx, "11111111")
+
+		if next.calls != 3 {
+			t.Errorf("expected 3 upstream calls, got %d instead", next.calls)
+		}
+
+		_, _ = sut.Load(ctx, "22222222")
+
+		if next.calls != 4 {
+			t.Errorf("expected evicted entry to be reloaded, got %d upstream calls instead", next.calls)
+		}
+	})
+
+	t.Run("CachedLoader should collapse concurrent misses into a single call", func(t *testing.T) {
+		var calls atomic.Int32
+		release := make(chan struct{})
+		next := &fakeLoader{
+			name: "next",
+			load: func(ctx context.Context, cep string) (CEP, error) {
+				calls.Add(1)
+				<-release
+				return CEP{Cep: cep}, nil
+			},
+		}
+		sut := NewCachedLoader(next, 10, time.Hour, time.Minute)
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = sut.Load(context.Background(), "25808110")
+			}()
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if got := calls.Load(); got != 1 {
+			t.Errorf("expected 1 upstream call, got %d instead", got)
+		}
+	})
+
+	t.Run("CachedLoader should give up on a shared call that outlives its timeout", func(t *testing.T) {
+		next := &fakeLoader{
+			name: "next",
+			load: func(ctx context.Context, cep string) (CEP, error) {
+				<-ctx.Done()
+				return CEP{}, ctx.Err()
+			},
+		}
+		sut := NewCachedLoader(next, 10, time.Hour, time.Minute)
+		sut.loadTimeout = 20 * time.Millisecond
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := sut.Load(context.Background(), "25808110")
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("expected deadline exceeded, got '%v' instead", err)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("expected the shared call to time out")
+		}
+	})
+}
+
+func TestCachedLoader_Peek(t *testing.T) {
+	t.Run("Peek should return the last known address even after it expired", func(t *testing.T) {
+		next := succeedingLoader("next")
+		sut := NewCachedLoader(next, 10, time.Hour, time.Minute)
+		now := time.Now()
+		sut.now = func() time.Time { return now }
+
+		_, _ = sut.Load(context.Background(), "25808110")
+		now = now.Add(2 * time.Hour)
+		got, ok := sut.Peek("25808110")
+
+		if !ok {
+			t.Errorf("expected expired entry to be peeked, got nothing instead")
+		}
+
+		if got.City != "Três Rios" {
+			t.Errorf("expected city to be Três Rios, got '%s' instead", got.City)
+		}
+
+		if next.calls != 1 {
+			t.Errorf("expected Peek not to call the wrapped loader, got %d upstream calls instead", next.calls)
+		}
+	})
+
+	t.Run("Peek should ignore unknown and not found CEPs", func(t *testing.T) {
+		sut := NewCachedLoader(failingLoader("next", ErrCEPNotFound), 10, time.Hour, time.Minute)
+
+		_, _ = sut.Load(context.Background(), "99999999")
+
+		if _, ok := sut.Peek("99999999"); ok {
+			t.Errorf("expected not found CEP not to be peeked")
+		}
+
+		if _, ok := sut.Peek("25808110"); ok {
+			t.Errorf("expected unknown CEP not to be peeked")
+		}
+	})
+}
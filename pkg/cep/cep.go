@@ -3,6 +3,7 @@ package cep
 import (
 	"context"
 	"errors"
+	"fmt"
 )
 
 type CEP struct {
@@ -20,6 +21,15 @@ var ErrCEPNotFound = errors.New("CEP not found")
 var ErrInvalidCEP = errors.New("invalid CEP")
 var ErrServiceUnavailable = errors.New("service unavailable")
 
+// ErrNoCoordinates is returned by the composite loaders when a provider
+// found the CEP but, like ViaCEP, could not tell where it is.
+var ErrNoCoordinates = fmt.Errorf("%w: no coordinates", ErrServiceUnavailable)
+
+// HasCoordinates reports whether the provider located the CEP.
+func (c CEP) HasCoordinates() bool {
+	return c.Latitude != "" && c.Longitude != ""
+}
+
 type Loader interface {
 	Load(ctx context.Context, cep string) (CEP, error)
 }
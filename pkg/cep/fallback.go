@@ -0,0 +1,104 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/provider"
+)
+
+// FallbackLoader tries each loader in order until one of them answers.
+// Unavailable providers, transport errors and answers without coordinates
+// move on to the next loader, while ErrInvalidCEP and ErrCEPNotFound are
+// returned right away since another provider would not change the
+// outcome.
+type FallbackLoader struct {
+	tracer  trace.Tracer
+	loaders []Loader
+}
+
+var _ Loader = &FallbackLoader{}
+
+func NewFallbackLoader(tracer trace.Tracer, loaders ...Loader) *FallbackLoader {
+	return &FallbackLoader{
+		tracer:  tracer,
+		loaders: loaders,
+	}
+}
+
+// Warmup warms up every wrapped loader.
+func (l *FallbackLoader) Warmup(ctx context.Context) error {
+	return provider.WarmupAll(ctx, l.loaders)
+}
+
+func (l *FallbackLoader) Load(ctx context.Context, cep string) (CEP, error) {
+	errs := make([]error, 0, len(l.loaders))
+	for i, loader := range l.loaders {
+		name := provider.Name(loader)
+		attemptCtx, span := l.tracer.Start(ctx, "cep-provider", trace.WithAttributes(
+			attribute.String("cep.provider", name),
+			attribute.Int("cep.attempt", i+1),
+		))
+
+		c, err := located(loader.Load(attemptCtx, cep))
+		endAttemptSpan(span, err)
+		if err == nil {
+			if c.Service == "" {
+				c.Service = name
+			}
+			return c, nil
+		}
+
+		if !shouldFallThrough(ctx, err) {
+			return CEP{}, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+
+	return CEP{}, fmt.Errorf("%w: all providers failed: %w", ErrServiceUnavailable, errors.Join(errs...))
+}
+
+// located turns an answer without coordinates into ErrNoCoordinates, as
+// the weather lookup that follows can not use it.
+func located(c CEP, err error) (CEP, error) {
+	if err == nil && !c.HasCoordinates() {
+		return CEP{}, ErrNoCoordinates
+	}
+	return c, err
+}
+
+// shouldFallThrough reports whether a failed attempt is worth retrying
+// with another provider.
+func shouldFallThrough(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return !errors.Is(err, ErrInvalidCEP) && !errors.Is(err, ErrCEPNotFound)
+}
+
+func attemptOutcome(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, ErrInvalidCEP):
+		return "invalid_cep"
+	case errors.Is(err, ErrCEPNotFound):
+		return "not_found"
+	case errors.Is(err, ErrNoCoordinates):
+		return "no_coordinates"
+	case errors.Is(err, ErrServiceUnavailable):
+		return "unavailable"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	default:
+		return "error"
+	}
+}
+
+func endAttemptSpan(span trace.Span, err error) {
+	provider.EndAttempt(span, attribute.String("cep.outcome", attemptOutcome(err)), err, "cep provider failed")
+}
@@ -0,0 +1,162 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type fakeLoader struct {
+	name  string
+	calls int
+	load  func(ctx context.Context, cep string) (CEP, error)
+}
+
+func (l *fakeLoader) Name() string {
+	return l.name
+}
+
+func (l *fakeLoader) Load(ctx context.Context, cep string) (CEP, error) {
+	l.calls++
+	return l.load(ctx, cep)
+}
+
+func failingLoader(name string, err error) *fakeLoader {
+	return &fakeLoader{
+		name: name,
+		load: func(ctx context.Context, cep string) (CEP, error) {
+			return CEP{}, err
+		},
+	}
+}
+
+func succeedingLoader(name string) *fakeLoader {
+	return &fakeLoader{
+		name: name,
+		load: func(ctx context.Context, cep string) (CEP, error) {
+			return CEP{Cep: cep, City: "Três Rios", Latitude: "-22.09967", Longitude: "-43.2116"}, nil
+		},
+	}
+}
+
+func TestFallbackLoader_Load(t *testing.T) {
+	tracer := noop.NewTracerProvider().Tracer("test")
+
+	t.Run("FallbackLoader should fall through unavailable and transport errors", func(t *testing.T) {
+		first := failingLoader("first", ErrServiceUnavailable)
+		second := failingLoader("second", errors.New("connection refused"))
+		third := succeedingLoader("third")
+		sut := NewFallbackLoader(tracer, first, second, third)
+
+		got, err := sut.Load(context.Background(), "25808110")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.Service != "third" {
+			t.Errorf("expected service to be third, got '%s' instead", got.Service)
+		}
+	})
+
+	t.Run("FallbackLoader should fall through answers without coordinates", func(t *testing.T) {
+		unlocated := &fakeLoader{
+			name: "unlocated",
+			load: func(ctx context.Context, cep string) (CEP, error) {
+				return CEP{Cep: cep, City: "Três Rios"}, nil
+			},
+		}
+		sut := NewFallbackLoader(tracer, unlocated, succeedingLoader("second"))
+
+		got, err := sut.Load(context.Background(), "25808110")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.Service != "second" || !got.HasCoordinates() {
+			t.Errorf("expected second to locate the CEP, got %+v instead", got)
+		}
+	})
+
+	t.Run("FallbackLoader should report no coordinates when no provider has them", func(t *testing.T) {
+		unlocated := &fakeLoader{
+			name: "unlocated",
+			load: func(ctx context.Context, cep string) (CEP, error) {
+				return CEP{Cep: cep, City: "Três Rios"}, nil
+			},
+		}
+		sut := NewFallbackLoader(tracer, unlocated)
+
+		_, err := sut.Load(context.Background(), "25808110")
+
+		if !errors.Is(err, ErrNoCoordinates) || !errors.Is(err, ErrServiceUnavailable) {
+			t.Errorf("expected no coordinates error, got '%v' instead", err)
+		}
+	})
+
+	t.Run("FallbackLoader should stop on invalid or not found CEP", func(t *testing.T) {
+		for _, want := range []error{ErrInvalidCEP, ErrCEPNotFound} {
+			first := failingLoader("first", want)
+			second := succeedingLoader("second")
+			sut := NewFallbackLoader(tracer, first, second)
+
+			_, err := sut.Load(context.Background(), "25808110")
+
+			if !errors.Is(err, want) {
+				t.Errorf("expected '%v', got '%v' instead", want, err)
+			}
+
+			if second.calls != 0 {
+				t.Errorf("expected second loader not to be called, got %d calls instead", second.calls)
+			}
+		}
+	})
+
+	t.Run("FallbackLoader should return service unavailable when every provider fails", func(t *testing.T) {
+		transportErr := errors.New("connection refused")
+		sut := NewFallbackLoader(tracer,
+			failingLoader("first", ErrServiceUnavailable),
+			failingLoader("second", transportErr),
+		)
+
+		_, err := sut.Load(context.Background(), "25808110")
+
+		if !errors.Is(err, ErrServiceUnavailable) {
+			t.Errorf("expected service unavailable error, got '%v' instead", err)
+		}
+
+		if !errors.Is(err, transportErr) {
+			t.Errorf("expected error to wrap the transport error, got '%v' instead", err)
+		}
+	})
+
+	t.Run("FallbackLoader should start one child span per attempt", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		tracer := tp.Tracer("test")
+		ctx, parent := tracer.Start(context.Background(), "cep-loader")
+		sut := NewFallbackLoader(tracer,
+			failingLoader("first", ErrServiceUnavailable),
+			succeedingLoader("second"),
+		)
+
+		_, _ = sut.Load(ctx, "25808110")
+		parent.End()
+
+		spans := recorder.Ended()
+		if len(spans) != 3 {
+			t.Fatalf("expected 3 spans, got %d instead", len(spans))
+		}
+
+		for _, span := range spans[:2] {
+			if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+				t.Errorf("expected span '%s' to be a child of cep-loader", span.Name())
+			}
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package cep
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/httpclient"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/retry"
+)
+
+type config struct {
+	httpclient.Config
+}
+
+// Option configures the HTTP based loaders of this package.
+type Option func(*config) error
+
+// WithBaseURL overrides the provider base URL, useful to point a loader
+// at a local stand-in during tests.
+func WithBaseURL(baseURL string) Option {
+	return func(c *config) error {
+		return c.SetBaseURL(baseURL)
+	}
+}
+
+// WithHTTPClient sets the client used to reach the provider. It can not
+// be combined with the TLS and retry options, which only apply to the
+// default client.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) error {
+		return c.SetHTTPClient(client)
+	}
+}
+
+// WithRetryPolicy sets how the default client retries transient provider
+// failures, replacing retry.DefaultPolicy.
+func WithRetryPolicy(policy retry.Policy) Option {
+	return func(c *config) error {
+		c.SetRetryPolicy(policy)
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the default client.
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(c *config) error {
+		return c.SetTLSConfig(tlsConfig)
+	}
+}
+
+// WithCABundle trusts the PEM encoded certificates found at path, on top
+// of the system roots, when verifying the provider certificate.
+func WithCABundle(path string) Option {
+	return func(c *config) error {
+		return c.LoadCABundle(path)
+	}
+}
+
+func newConfig(baseURL string, opts []Option) (config, error) {
+	c := config{Config: httpclient.Config{BaseURL: baseURL}}
+	for _, opt := range opts {
+		if err := opt(&c); err != nil {
+			return config{}, err
+		}
+	}
+	if err := c.Build(); err != nil {
+		return config{}, err
+	}
+	return c, nil
+}
@@ -0,0 +1,136 @@
+package cep
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/provider"
+)
+
+func newAwesomeAPITLSServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"cep":"25808110","city":"Três Rios","lat":"-22.09967","lng":"-43.2116"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func writeCABundle(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(path, bundle, 0o600); err != nil {
+		t.Fatalf("could not write ca bundle: %v", err)
+	}
+	return path
+}
+
+func TestOptions(t *testing.T) {
+	srv := newAwesomeAPITLSServer(t)
+
+	t.Run("Loaders should verify provider certificates by default", func(t *testing.T) {
+		sut, _ := NewAwesomeAPILoader(WithBaseURL(srv.URL))
+
+		_, err := sut.Load(context.Background(), "25808110")
+
+		if err == nil {
+			t.Errorf("expected certificate verification error, got nil instead")
+		}
+	})
+
+	t.Run("WithCABundle should trust the certificates in the bundle", func(t *testing.T) {
+		sut, err := NewAwesomeAPILoader(WithBaseURL(srv.URL), WithCABundle(writeCABundle(t, srv)))
+		if err != nil {
+			t.Fatalf("expected error to be nil, got '%v' instead", err)
+		}
+
+		got, err := sut.Load(context.Background(), "25808110")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.Latitude != "-22.09967" {
+			t.Errorf("expected latitude to be -22.09967, got '%s' instead", got.Latitude)
+		}
+	})
+
+	t.Run("WithTLSConfig should configure the default client", func(t *testing.T) {
+		sut, _ := NewAwesomeAPILoader(WithBaseURL(srv.URL), WithTLSConfig(&tls.Config{InsecureSkipVerify: true}))
+
+		_, err := sut.Load(context.Background(), "25808110")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+	})
+
+	t.Run("WithCABundle should fail on a missing bundle", func(t *testing.T) {
+		_, err := NewAwesomeAPILoader(WithCABundle(filepath.Join(t.TempDir(), "missing.pem")))
+
+		if err == nil {
+			t.Errorf("expected error on missing ca bundle, got nil instead")
+		}
+	})
+
+	t.Run("TLS options should not be combined with a custom client", func(t *testing.T) {
+		_, err := NewAwesomeAPILoader(WithHTTPClient(srv.Client()), WithTLSConfig(&tls.Config{}))
+
+		if err == nil {
+			t.Errorf("expected error on conflicting options, got nil instead")
+		}
+	})
+}
+
+func TestWarmup(t *testing.T) {
+	t.Run("Warmup should reach every provider behind a composite loader", func(t *testing.T) {
+		var heads atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				heads.Add(1)
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		awesome, _ := NewAwesomeAPILoader(WithBaseURL(srv.URL))
+		brasil, _ := NewBrasilAPILoader(WithBaseURL(srv.URL))
+		sut := NewCachedLoader(NewFallbackLoader(noop.NewTracerProvider().Tracer("test"), awesome, brasil, succeedingLoader("fake")), 10, time.Hour, time.Minute)
+
+		err := provider.Warmup(context.Background(), sut)
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if heads.Load() != 2 {
+			t.Errorf("expected 2 warmup requests, got %d instead", heads.Load())
+		}
+	})
+
+	t.Run("Warmup should report unreachable providers", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		srv.Close()
+
+		sut, _ := NewViaCEPLoader(WithBaseURL(srv.URL))
+
+		err := provider.Warmup(context.Background(), sut)
+
+		if err == nil {
+			t.Errorf("expected error on unreachable provider, got nil instead")
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/provider"
+)
+
+// RaceLoader sends the same lookup to several loaders concurrently and
+// returns the first successful answer with coordinates, cancelling the
+// remaining calls.
+//
+// With a positive hedge delay the loaders are started one at a time,
+// each one after the delay has passed without an answer, or right away
+// when every in-flight call has already failed.
+type RaceLoader struct {
+	tracer     trace.Tracer
+	hedgeDelay time.Duration
+	loaders    []Loader
+}
+
+var _ Loader = &RaceLoader{}
+
+func NewRaceLoader(tracer trace.Tracer, hedgeDelay time.Duration, loaders ...Loader) *RaceLoader {
+	return &RaceLoader{
+		tracer:     tracer,
+		hedgeDelay: hedgeDelay,
+		loaders:    loaders,
+	}
+}
+
+type raceResult struct {
+	name string
+	cep  CEP
+	err  error
+}
+
+// Warmup warms up every wrapped loader.
+func (l *RaceLoader) Warmup(ctx context.Context) error {
+	return provider.WarmupAll(ctx, l.loaders)
+}
+
+func (l *RaceLoader) Load(ctx context.Context, cep string) (CEP, error) {
+	if !Valid(cep) {
+		return CEP{}, ErrInvalidCEP
+	}
+
+	if len(l.loaders) == 0 {
+		return CEP{}, ErrServiceUnavailable
+	}
+
+	raceCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan raceResult, len(l.loaders))
+	started := 0
+	start := func() {
+		go l.attempt(raceCtx, started, cep, results)
+		started++
+	}
+
+	start()
+	for l.hedgeDelay <= 0 && started < len(l.loaders) {
+		start()
+	}
+
+	hedge := time.NewTimer(l.hedgeDelay)
+	defer hedge.Stop()
+
+	errs := make([]error, 0, len(l.loaders))
+	for finished := 0; finished < len(l.loaders); {
+		var hedgeC <-chan time.Time
+		if started < len(l.loaders) {
+			hedgeC = hedge.C
+		}
+
+		select {
+		case <-hedgeC:
+			start()
+			hedge.Reset(l.hedgeDelay)
+
+		case r := <-results:
+			finished++
+			if r.err == nil {
+				cancel()
+				if r.cep.Service == "" {
+					r.cep.Service = r.name
+				}
+				trace.SpanFromContext(ctx).SetAttributes(attribute.String("cep.race.winner", r.name))
+				return r.cep, nil
+			}
+
+			errs = append(errs, fmt.Errorf("%s: %w", r.name, r.err))
+			if finished == started && started < len(l.loaders) {
+				start()
+				resetTimer(hedge, l.hedgeDelay)
+			}
+		}
+	}
+
+	return CEP{}, fmt.Errorf("%w: all providers failed: %w", ErrServiceUnavailable, errors.Join(errs...))
+}
+
+func (l *RaceLoader) attempt(ctx context.Context, i int, cep string, results chan<- raceResult) {
+	loader := l.loaders[i]
+	name := provider.Name(loader)
+	attemptCtx, span := l.tracer.Start(ctx, "cep-provider", trace.WithAttributes(
+		attribute.String("cep.provider", name),
+		attribute.Int("cep.attempt", i+1),
+	))
+
+	c, err := located(loader.Load(attemptCtx, cep))
+	endAttemptSpan(span, err)
+	results <- raceResult{name: name, cep: c, err: err}
+}
+
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
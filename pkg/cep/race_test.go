@@ -0,0 +1,183 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func slowLoader(name string, delay time.Duration) *fakeLoader {
+	return &fakeLoader{
+		name: name,
+		load: func(ctx context.Context, cep string) (CEP, error) {
+			select {
+			case <-time.After(delay):
+				return CEP{Cep: cep, Latitude: "-22.09967", Longitude: "-43.2116"}, nil
+			case <-ctx.Done():
+				return CEP{}, ctx.Err()
+			}
+		},
+	}
+}
+
+func TestRaceLoader_Load(t *testing.T) {
+	tracer := noop.NewTracerProvider().Tracer("test")
+
+	t.Run("RaceLoader should return error on invalid CEP without calling providers", func(t *testing.T) {
+		first := succeedingLoader("first")
+		sut := NewRaceLoader(tracer, 0, first)
+
+		_, err := sut.Load(context.Background(), "invalid-cep")
+
+		if !errors.Is(err, ErrInvalidCEP) {
+			t.Errorf("expected invalid CEP error, got '%v' instead", err)
+		}
+
+		if first.calls != 0 {
+			t.Errorf("expected provider not to be called, got %d calls instead", first.calls)
+		}
+	})
+
+	t.Run("RaceLoader should return the fastest provider and cancel the others", func(t *testing.T) {
+		cancelled := make(chan struct{})
+		slow := &fakeLoader{
+			name: "slow",
+			load: func(ctx context.Context, cep string) (CEP, error) {
+				<-ctx.Done()
+				close(cancelled)
+				return CEP{}, ctx.Err()
+			},
+		}
+		sut := NewRaceLoader(tracer, 0, slow, slowLoader("fast", 10*time.Millisecond))
+
+		got, err := sut.Load(context.Background(), "25808110")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.Service != "fast" {
+			t.Errorf("expected service to be fast, got '%s' instead", got.Service)
+		}
+
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			t.Errorf("expected the losing provider to be cancelled")
+		}
+	})
+
+	t.Run("RaceLoader should not fire hedged calls when the first provider answers in time", func(t *testing.T) {
+		first := slowLoader("first", 5*time.Millisecond)
+		second := succeedingLoader("second")
+		sut := NewRaceLoader(tracer, time.Second, first, second)
+
+		got, err := sut.Load(context.Background(), "25808110")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.Service != "first" {
+			t.Errorf("expected service to be first, got '%s' instead", got.Service)
+		}
+	})
+
+	t.Run("RaceLoader should fire the next provider right away when the previous one fails", func(t *testing.T) {
+		sut := NewRaceLoader(tracer, time.Minute,
+			failingLoader("first", ErrServiceUnavailable),
+			succeedingLoader("second"),
+		)
+
+		got, err := sut.Load(context.Background(), "25808110")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.Service != "second" {
+			t.Errorf("expected service to be second, got '%s' instead", got.Service)
+		}
+	})
+
+	t.Run("RaceLoader should skip answers without coordinates", func(t *testing.T) {
+		unlocated := &fakeLoader{
+			name: "unlocated",
+			load: func(ctx context.Context, cep string) (CEP, error) {
+				return CEP{Cep: cep, City: "Três Rios"}, nil
+			},
+		}
+		sut := NewRaceLoader(tracer, 0, unlocated, slowLoader("located", 10*time.Millisecond))
+
+		got, err := sut.Load(context.Background(), "25808110")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.Service != "located" {
+			t.Errorf("expected service to be located, got '%s' instead", got.Service)
+		}
+	})
+
+	t.Run("RaceLoader should join every error when all providers fail", func(t *testing.T) {
+		transportErr := errors.New("connection refused")
+		notFound := fmt.Errorf("lookup: %w", ErrCEPNotFound)
+		sut := NewRaceLoader(tracer, 0,
+			failingLoader("first", transportErr),
+			failingLoader("second", notFound),
+		)
+
+		_, err := sut.Load(context.Background(), "25808110")
+
+		if !errors.Is(err, transportErr) || !errors.Is(err, notFound) {
+			t.Errorf("expected error to join every provider error, got '%v' instead", err)
+		}
+	})
+
+	t.Run("RaceLoader should return service unavailable when every provider fails", func(t *testing.T) {
+		sut := NewRaceLoader(tracer, 0,
+			failingLoader("first", errors.New("connection refused")),
+			failingLoader("second", errors.New("connection reset")),
+		)
+
+		_, err := sut.Load(context.Background(), "25808110")
+
+		if !errors.Is(err, ErrServiceUnavailable) {
+			t.Errorf("expected service unavailable error, got '%v' instead", err)
+		}
+	})
+
+	t.Run("RaceLoader should record the winner on the active span", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		tracer := tp.Tracer("test")
+		ctx, parent := tracer.Start(context.Background(), "cep-loader")
+		sut := NewRaceLoader(tracer, 0, succeedingLoader("winner"))
+
+		_, _ = sut.Load(ctx, "25808110")
+		parent.End()
+
+		var winner string
+		for _, span := range recorder.Ended() {
+			if span.Name() != "cep-loader" {
+				continue
+			}
+			for _, attr := range span.Attributes() {
+				if attr.Key == "cep.race.winner" {
+					winner = attr.Value.AsString()
+				}
+			}
+		}
+
+		if winner != "winner" {
+			t.Errorf("expected winner attribute to be winner, got '%s' instead", winner)
+		}
+	})
+}
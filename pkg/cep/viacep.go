@@ -0,0 +1,119 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/httpclient"
+)
+
+const viaCEPBaseURL = "https://viacep.com.br"
+
+// viaCEPFlag handles the "erro" field, which ViaCEP has served both as
+// a boolean and as the string "true".
+type viaCEPFlag bool
+
+func (f *viaCEPFlag) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true":
+		*f = true
+	default:
+		*f = false
+	}
+	return nil
+}
+
+type viaCEPResponse struct {
+	Cep          string     `json:"cep"`
+	Street       string     `json:"logradouro"`
+	Neighborhood string     `json:"bairro"`
+	City         string     `json:"localidade"`
+	State        string     `json:"uf"`
+	Erro         viaCEPFlag `json:"erro"`
+}
+
+type ViaCEPLoader struct {
+	baseURL string
+	client  *http.Client
+}
+
+var _ Loader = &ViaCEPLoader{}
+
+func NewViaCEPLoader(opts ...Option) (*ViaCEPLoader, error) {
+	c, err := newConfig(viaCEPBaseURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &ViaCEPLoader{
+		baseURL: c.BaseURL,
+		client:  c.Client,
+	}, nil
+}
+
+func (l *ViaCEPLoader) Name() string {
+	return "ViaCEP"
+}
+
+func (l *ViaCEPLoader) Warmup(ctx context.Context) error {
+	return httpclient.Warmup(ctx, l.client, l.baseURL)
+}
+
+func (l *ViaCEPLoader) Load(ctx context.Context, cep string) (CEP, error) {
+	if !Valid(cep) {
+		return CEP{}, ErrInvalidCEP
+	}
+
+	url := fmt.Sprintf("%s/ws/%s/json/", l.baseURL, cep)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return CEP{}, err
+	}
+
+	res, err := l.client.Do(req)
+	if err != nil {
+		return CEP{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 400 {
+		return CEP{}, ErrInvalidCEP
+	}
+
+	if res.StatusCode == 404 {
+		return CEP{}, ErrCEPNotFound
+	}
+
+	if res.StatusCode != 200 {
+		return CEP{}, ErrServiceUnavailable
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return CEP{}, err
+	}
+
+	var b viaCEPResponse
+	err = json.Unmarshal(body, &b)
+	if err != nil {
+		return CEP{}, err
+	}
+
+	if b.Erro {
+		return CEP{}, ErrCEPNotFound
+	}
+
+	c := CEP{
+		Cep:          strings.ReplaceAll(b.Cep, "-", ""),
+		Street:       b.Street,
+		Neighborhood: b.Neighborhood,
+		City:         b.City,
+		State:        b.State,
+		Service:      l.Name(),
+	}
+
+	return c, nil
+}
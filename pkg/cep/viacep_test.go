@@ -0,0 +1,104 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newViaCEPServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ws/25808110/json/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"cep":"25808-110","logradouro":"Rua Exemplo","bairro":"Centro","localidade":"Três Rios","uf":"RJ"}`))
+	})
+	mux.HandleFunc("GET /ws/99999999/json/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"erro": true}`))
+	})
+	mux.HandleFunc("GET /ws/88888888/json/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"erro": "true"}`))
+	})
+	mux.HandleFunc("GET /ws/77777777/json/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestViaCEPLoader_Load(t *testing.T) {
+	srv := newViaCEPServer(t)
+
+	t.Run("ViaCEP should return error on invalid CEP", func(t *testing.T) {
+		sut, _ := NewViaCEPLoader(WithBaseURL(srv.URL))
+		ctx := context.Background()
+
+		_, err := sut.Load(ctx, "invalid-cep")
+
+		if !errors.Is(err, ErrInvalidCEP) {
+			t.Errorf("expected invalid CEP error, got '%v' instead", err)
+		}
+	})
+
+	t.Run("ViaCEP should return cep not found error when the payload has the erro flag", func(t *testing.T) {
+		sut, _ := NewViaCEPLoader(WithBaseURL(srv.URL))
+		ctx := context.Background()
+
+		for _, cep := range []string{"99999999", "88888888"} {
+			_, err := sut.Load(ctx, cep)
+
+			if !errors.Is(err, ErrCEPNotFound) {
+				t.Errorf("(%s): expected CEP not found error, got '%v' instead", cep, err)
+			}
+		}
+	})
+
+	t.Run("ViaCEP should return service unavailable error on server failure", func(t *testing.T) {
+		sut, _ := NewViaCEPLoader(WithBaseURL(srv.URL))
+		ctx := context.Background()
+
+		_, err := sut.Load(ctx, "77777777")
+
+		if !errors.Is(err, ErrServiceUnavailable) {
+			t.Errorf("expected service unavailable error, got '%v' instead", err)
+		}
+	})
+
+	t.Run("ViaCEP should return address on valid cep", func(t *testing.T) {
+		sut, _ := NewViaCEPLoader(WithBaseURL(srv.URL))
+		ctx := context.Background()
+
+		got, err := sut.Load(ctx, "25808110")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.Cep != "25808110" {
+			t.Errorf("expected cep to be 25808110, got '%s' instead", got.Cep)
+		}
+
+		if got.City != "Três Rios" {
+			t.Errorf("expected city to be Três Rios, got '%s' instead", got.City)
+		}
+
+		if got.Service != "ViaCEP" {
+			t.Errorf("expected service to be ViaCEP, got '%s' instead", got.Service)
+		}
+	})
+
+	t.Run("NewViaCEPLoader should reject an invalid base url", func(t *testing.T) {
+		_, err := NewViaCEPLoader(WithBaseURL("not a url"))
+
+		if err == nil {
+			t.Errorf("expected error on invalid base url, got nil instead")
+		}
+	})
+}
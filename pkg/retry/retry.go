@@ -0,0 +1,232 @@
+// Package retry retries outbound HTTP calls that failed for transient
+// reasons, waiting an exponential backoff with jitter between attempts.
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultMaxAttempts = 3
+	defaultBaseDelay   = 100 * time.Millisecond
+	defaultMaxDelay    = 2 * time.Second
+)
+
+// Policy describes how failed calls are retried.
+type Policy struct {
+	// MaxAttempts is the total number of attempts, the first one
+	// included. Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the backoff before the second attempt. Every later
+	// attempt doubles it, up to MaxDelay.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff. A Retry-After asking for a longer wait
+	// ends the retries instead.
+	MaxDelay time.Duration
+	// ShouldRetry classifies the outcome of an attempt. Transient is
+	// used when it is nil.
+	ShouldRetry func(resp *http.Response, err error) bool
+}
+
+// DefaultPolicy makes up to three attempts, starting with a 100ms backoff.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: defaultMaxAttempts,
+		BaseDelay:   defaultBaseDelay,
+		MaxDelay:    defaultMaxDelay,
+	}
+}
+
+// Transient reports whether an attempt failed for a reason that may go
+// away on its own: network errors, rate limiting and 5xx answers other
+// than 501. Cancelled and expired contexts are never retried.
+func Transient(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// ConnectionErrors reports whether an attempt failed before the server
+// could act on it, because the connection could not be opened. Any answer,
+// 502 included, may come after the server did the work, so none is
+// retried. Unlike Transient, it suits calls that must not run twice.
+// Cancelled and expired contexts are never retried.
+func ConnectionErrors(resp *http.Response, err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// Backoff returns the delay before the given attempt, counting from 2.
+// Half of the exponential delay is fixed and the other half is random,
+// so callers failing together do not retry together.
+func (p Policy) Backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 2; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+func (p Policy) shouldRetry(resp *http.Response, err error) bool {
+	if p.ShouldRetry != nil {
+		return p.ShouldRetry(resp, err)
+	}
+	return Transient(resp, err)
+}
+
+// Transport is an http.RoundTripper retrying calls according to a Policy.
+// Requests with a body are only retried when it can be replayed through
+// GetBody. Every attempt is recorded as a "retry.attempt" event on the
+// span of the request context, so the transport should sit below the one
+// creating client spans.
+//
+// Only use it for calls that are safe to repeat.
+type Transport struct {
+	base   http.RoundTripper
+	policy Policy
+}
+
+var _ http.RoundTripper = &Transport{}
+
+func NewTransport(base http.RoundTripper, policy Policy) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{base: base, policy: policy}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	span := trace.SpanFromContext(ctx)
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+
+		retry := attempt < t.policy.MaxAttempts && t.policy.shouldRetry(resp, err) && replayable(req)
+		var delay time.Duration
+		if retry {
+			delay, retry = t.delay(ctx, attempt+1, resp)
+		}
+
+		attrs := []attribute.KeyValue{
+			attribute.Int("retry.attempt", attempt),
+			attribute.Bool("retry.will_retry", retry),
+		}
+		if err != nil {
+			attrs = append(attrs, attribute.String("error.message", err.Error()))
+		} else {
+			attrs = append(attrs, attribute.Int("http.response.status_code", resp.StatusCode))
+		}
+		if retry {
+			attrs = append(attrs, attribute.Int64("retry.delay_ms", delay.Milliseconds()))
+		}
+		span.AddEvent("retry.attempt", trace.WithAttributes(attrs...))
+
+		if !retry {
+			return resp, err
+		}
+		discard(resp)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		if req, err = rewind(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// delay picks the wait before the next attempt, preferring the server's
+// Retry-After. It gives up when the wait exceeds MaxDelay or would not
+// leave the next attempt any time before the context deadline.
+func (t *Transport) delay(ctx context.Context, attempt int, resp *http.Response) (time.Duration, bool) {
+	delay := t.policy.Backoff(attempt)
+	if resp != nil {
+		if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			if t.policy.MaxDelay > 0 && after > t.policy.MaxDelay {
+				return 0, false
+			}
+			delay = after
+		}
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+		return 0, false
+	}
+	return delay, true
+}
+
+// retryAfter parses a Retry-After header holding either a number of
+// seconds or an HTTP date.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	next := req.Clone(req.Context())
+	next.Body = body
+	return next, nil
+}
+
+// discard drains a bit of the body so the connection can be reused.
+func discard(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,224 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func testPolicy() Policy {
+	return Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+}
+
+// flakyServer answers each call with the next status in statuses, and
+// with the last one after they run out.
+func flakyServer(t *testing.T, calls *atomic.Int32, header http.Header, statuses ...int) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(statuses[min(n, len(statuses))-1])
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestTransport_RoundTrip(t *testing.T) {
+	t.Run("Transport should retry transient failures until one succeeds", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := flakyServer(t, &calls, nil, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK)
+		sut := &http.Client{Transport: NewTransport(nil, testPolicy())}
+
+		resp, err := sut.Get(srv.URL)
+
+		if err != nil {
+			t.Fatalf("expected error to be nil, got '%v' instead", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status 200, got %d instead", resp.StatusCode)
+		}
+
+		if calls.Load() != 3 {
+			t.Errorf("expected 3 attempts, got %d instead", calls.Load())
+		}
+	})
+
+	t.Run("Transport should give up after the max attempts", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := flakyServer(t, &calls, nil, http.StatusServiceUnavailable)
+		sut := &http.Client{Transport: NewTransport(nil, testPolicy())}
+
+		resp, err := sut.Get(srv.URL)
+
+		if err != nil {
+			t.Fatalf("expected error to be nil, got '%v' instead", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("expected last status 503, got %d instead", resp.StatusCode)
+		}
+
+		if calls.Load() != 3 {
+			t.Errorf("expected 3 attempts, got %d instead", calls.Load())
+		}
+	})
+
+	t.Run("Transport should not retry client errors", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := flakyServer(t, &calls, nil, http.StatusBadRequest)
+		sut := &http.Client{Transport: NewTransport(nil, testPolicy())}
+
+		resp, _ := sut.Get(srv.URL)
+		resp.Body.Close()
+
+		if calls.Load() != 1 {
+			t.Errorf("expected 1 attempt, got %d instead", calls.Load())
+		}
+	})
+
+	t.Run("Transport should not retry when Retry-After exceeds the max delay", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := flakyServer(t, &calls, http.Header{"Retry-After": {"120"}}, http.StatusTooManyRequests)
+		sut := &http.Client{Transport: NewTransport(nil, testPolicy())}
+
+		resp, _ := sut.Get(srv.URL)
+		resp.Body.Close()
+
+		if calls.Load() != 1 {
+			t.Errorf("expected 1 attempt, got %d instead", calls.Load())
+		}
+	})
+
+	t.Run("Transport should not wait past the context deadline", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := flakyServer(t, &calls, nil, http.StatusServiceUnavailable)
+		policy := testPolicy()
+		policy.BaseDelay, policy.MaxDelay = time.Second, time.Second
+		sut := &http.Client{Transport: NewTransport(nil, policy)}
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		resp, err := sut.Do(req)
+
+		if err != nil {
+			t.Fatalf("expected error to be nil, got '%v' instead", err)
+		}
+		resp.Body.Close()
+
+		if calls.Load() != 1 {
+			t.Errorf("expected 1 attempt, got %d instead", calls.Load())
+		}
+	})
+
+	t.Run("Transport should replay the request body on every attempt", func(t *testing.T) {
+		var bodies []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(b))
+			if len(bodies) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		t.Cleanup(srv.Close)
+		sut := &http.Client{Transport: NewTransport(nil, testPolicy())}
+
+		resp, err := sut.Post(srv.URL, "application/json", bytes.NewBufferString(`{"cep":"25808110"}`))
+
+		if err != nil {
+			t.Fatalf("expected error to be nil, got '%v' instead", err)
+		}
+		resp.Body.Close()
+
+		if len(bodies) != 2 || bodies[1] != `{"cep":"25808110"}` {
+			t.Errorf("expected body to be sent twice, got %q instead", bodies)
+		}
+	})
+
+	t.Run("Transport should record every attempt as a span event", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := flakyServer(t, &calls, nil, http.StatusServiceUnavailable, http.StatusOK)
+		recorder := tracetest.NewSpanRecorder()
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+		sut := &http.Client{Transport: NewTransport(nil, testPolicy())}
+
+		ctx, span := tracer.Start(context.Background(), "client")
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		resp, _ := sut.Do(req)
+		resp.Body.Close()
+		span.End()
+
+		events := recorder.Ended()[0].Events()
+		if len(events) != 2 {
+			t.Fatalf("expected 2 span events, got %d instead", len(events))
+		}
+
+		if events[0].Name != "retry.attempt" {
+			t.Errorf("expected event to be retry.attempt, got '%s' instead", events[0].Name)
+		}
+	})
+}
+
+func TestConnectionErrors(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	tests := []struct {
+		name   string
+		status int
+		err    error
+		want   bool
+	}{
+		{name: "ConnectionErrors should retry connections that could not be opened", err: &url.Error{Op: "Post", URL: "http://orchestrator", Err: dialErr}, want: true},
+		{name: "ConnectionErrors should not retry connections lost after the request was sent", err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}},
+		{name: "ConnectionErrors should not retry dials cut by the deadline", err: &net.OpError{Op: "dial", Net: "tcp", Err: context.DeadlineExceeded}},
+		{name: "ConnectionErrors should not retry other errors", err: io.ErrUnexpectedEOF},
+		{name: "ConnectionErrors should not retry bad gateway answers, which may follow a whole orchestrator run", status: http.StatusBadGateway},
+		{name: "ConnectionErrors should not retry answers the server may have acted on", status: http.StatusServiceUnavailable},
+		{name: "ConnectionErrors should not retry internal errors", status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp *http.Response
+			if tt.err == nil {
+				resp = &http.Response{StatusCode: tt.status}
+			}
+
+			got := ConnectionErrors(resp, tt.err)
+
+			if got != tt.want {
+				t.Errorf("expected %t, got %t instead", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPolicy_Backoff(t *testing.T) {
+	t.Run("Backoff should grow exponentially up to the max delay", func(t *testing.T) {
+		sut := Policy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+		for attempt, want := range map[int]time.Duration{2: 100 * time.Millisecond, 3: 200 * time.Millisecond, 6: 300 * time.Millisecond} {
+			got := sut.Backoff(attempt)
+
+			if got < want/2 || got > want {
+				t.Errorf("expected backoff of attempt %d within [%v, %v], got %v instead", attempt, want/2, want, got)
+			}
+		}
+	})
+}
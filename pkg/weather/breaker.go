@@ -0,0 +1,67 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/provider"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/breaker"
+)
+
+// BreakerLoader guards a provider with a circuit breaker. While the
+// breaker is open, lookups fail right away with ErrServiceUnavailable
+// instead of waiting for a provider known to be down. ErrInvalidLocation
+// is a valid answer and does not count as a failure. Neither do
+// ErrQuotaExhausted and ErrUnauthorized: the provider is up but refused
+// our keys, and tripping the breaker would hide ErrQuotaExhausted from a
+// CachedLoader wrapping it, which then serves expired readings.
+type BreakerLoader struct {
+	next    Loader
+	breaker *breaker.Breaker
+}
+
+var _ Loader = &BreakerLoader{}
+
+func NewBreakerLoader(next Loader, b *breaker.Breaker) *BreakerLoader {
+	return &BreakerLoader{
+		next:    next,
+		breaker: b,
+	}
+}
+
+func (l *BreakerLoader) Name() string {
+	return provider.Name(l.next)
+}
+
+// Warmup warms up the wrapped loader.
+func (l *BreakerLoader) Warmup(ctx context.Context) error {
+	return provider.Warmup(ctx, l.next)
+}
+
+func (l *BreakerLoader) Load(ctx context.Context, lat, lng string) (Weather, error) {
+	// A caller that already gave up must not count against the provider.
+	if err := ctx.Err(); err != nil {
+		return Weather{}, err
+	}
+
+	done, err := l.breaker.Allow(ctx)
+	if err != nil {
+		return Weather{}, fmt.Errorf("%w: %s: %w", ErrServiceUnavailable, provider.Name(l.next), err)
+	}
+
+	w, err := l.next.Load(ctx, lat, lng)
+	if countsAsFailure(err) {
+		done(err)
+	} else {
+		done(nil)
+	}
+	return w, err
+}
+
+func countsAsFailure(err error) bool {
+	return err != nil &&
+		!errors.Is(err, ErrInvalidLocation) &&
+		!errors.Is(err, ErrQuotaExhausted) &&
+		!errors.Is(err, ErrUnauthorized)
+}
@@ -0,0 +1,136 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/breaker"
+)
+
+func testBreaker() *breaker.Breaker {
+	settings := breaker.DefaultSettings()
+	settings.MinRequests = 2
+	return breaker.New("test", settings)
+}
+
+func TestBreakerLoader_Load(t *testing.T) {
+	t.Run("BreakerLoader should fail fast once the provider keeps failing", func(t *testing.T) {
+		next := failingLoader("next", ErrServiceUnavailable)
+		sut := NewBreakerLoader(next, testBreaker())
+
+		_, _ = sut.Load(context.Background(), "-22.09967", "-43.2116")
+		_, _ = sut.Load(context.Background(), "-22.09967", "-43.2116")
+		_, err := sut.Load(context.Background(), "-22.09967", "-43.2116")
+
+		if !errors.Is(err, ErrServiceUnavailable) || !errors.Is(err, breaker.ErrOpen) {
+			t.Errorf("expected open breaker error, got '%v' instead", err)
+		}
+
+		if next.calls != 2 {
+			t.Errorf("expected 2 upstream calls, got %d instead", next.calls)
+		}
+	})
+
+	for _, tt := range []struct {
+		name string
+		err  error
+	}{
+		{name: "BreakerLoader should not count invalid locations as failures", err: ErrInvalidLocation},
+		{name: "BreakerLoader should not count an exhausted quota as a failure", err: ErrQuotaExhausted},
+		{name: "BreakerLoader should not count rejected keys as failures", err: fmt.Errorf("%w: key-1", ErrUnauthorized)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			next := failingLoader("next", tt.err)
+			sut := NewBreakerLoader(next, testBreaker())
+
+			for range 3 {
+				_, _ = sut.Load(context.Background(), "", "")
+			}
+
+			if next.calls != 3 {
+				t.Errorf("expected 3 upstream calls, got %d instead", next.calls)
+			}
+		})
+	}
+
+	t.Run("BreakerLoader should not count calls whose deadline already ran out", func(t *testing.T) {
+		next := failingLoader("next", ErrServiceUnavailable)
+		b := testBreaker()
+		sut := NewBreakerLoader(next, b)
+		ctx, cancel := context.WithTimeout(context.Background(), 0)
+		defer cancel()
+
+		for range 3 {
+			_, _ = sut.Load(ctx, "-22.09967", "-43.2116")
+		}
+
+		if next.calls != 0 {
+			t.Errorf("expected no upstream calls, got %d instead", next.calls)
+		}
+
+		if b.State() != breaker.Closed {
+			t.Errorf("expected breaker to stay closed, got %s instead", b.State())
+		}
+	})
+
+	t.Run("BreakerLoader should let the fallback skip an open provider", func(t *testing.T) {
+		down := failingLoader("down", ErrServiceUnavailable)
+		guarded := NewBreakerLoader(down, testBreaker())
+		_, _ = guarded.Load(context.Background(), "-22.09967", "-43.2116")
+		_, _ = guarded.Load(context.Background(), "-22.09967", "-43.2116")
+		sut := NewFallbackLoader(noop.NewTracerProvider().Tracer("test"), guarded, succeedingLoader("up", 20))
+
+		got, err := sut.Load(context.Background(), "-22.09967", "-43.2116")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.TempC != 20 {
+			t.Errorf("expected TempC to be 20, got %f instead", got.TempC)
+		}
+
+		if down.calls != 2 {
+			t.Errorf("expected open provider not to be called, got %d calls instead", down.calls)
+		}
+	})
+	t.Run("BreakerLoader should let the cache serve expired readings once the quota runs out", func(t *testing.T) {
+		exceeded := false
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if exceeded {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"error":{"code":2007,"message":"API key has exceeded calls per month quota."}}`))
+				return
+			}
+			w.Write([]byte(`{"current":{"temp_c":25.5}}`))
+		}))
+		t.Cleanup(srv.Close)
+		api, _ := NewWeatherAPILoader("key", WithBaseURL(srv.URL))
+		b := testBreaker()
+		sut := NewCachedLoader(NewBreakerLoader(api, b), 2, time.Minute, time.Minute)
+		now := time.Now()
+		sut.now = func() time.Time { return now }
+
+		_, _ = sut.Load(context.Background(), "-22.09967", "-43.2116")
+		now = now.Add(time.Hour)
+		exceeded = true
+		for range 3 {
+			got, err := sut.Load(context.Background(), "-22.09967", "-43.2116")
+
+			if err != nil || got.TempC != 25.5 {
+				t.Errorf("expected the expired reading, got (%+v, '%v') instead", got, err)
+			}
+		}
+
+		if b.State() != breaker.Closed {
+			t.Errorf("expected breaker to stay closed, got %s instead", b.State())
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package weather
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/provider"
+)
+
+const (
+	// cacheMaxEntries bounds the cache. Past it, the least recently used
+	// entry is dropped, however fresh.
+	cacheMaxEntries = 10_000
+	// cacheRefreshTimeout bounds background refreshes, which no longer
+	// belong to any request deadline.
+	cacheRefreshTimeout = 10 * time.Second
+)
+
+// CachedLoader keeps recent weather readings in memory, keyed by the
+// coordinates rounded to precision decimal places, so nearby locations
+// share the same entry.
+//
+// Entries are fresh for ttl. For staleTTL after that, the stale reading
+// is returned right away while a single background call refreshes it.
+// Once the provider quota is exhausted, any reading still stored is
+// returned, however old, rather than no reading at all.
+type CachedLoader struct {
+	next      Loader
+	precision int
+	ttl       time.Duration
+	staleTTL  time.Duration
+	now       func() time.Time
+
+	mu         sync.Mutex
+	maxEntries int
+	lru        *list.List
+	entries    map[string]*list.Element
+
+	hits      atomic.Int64
+	staleHits atomic.Int64
+	misses    atomic.Int64
+}
+
+type weatherEntry struct {
+	key        string
+	weather    Weather
+	storedAt   time.Time
+	refreshing bool
+}
+
+// CacheStats holds the CachedLoader counters since it was created.
+type CacheStats struct {
+	Hits      int64
+	StaleHits int64
+	Misses    int64
+}
+
+var _ Loader = &CachedLoader{}
+
+func NewCachedLoader(next Loader, precision int, ttl, staleTTL time.Duration) *CachedLoader {
+	return &CachedLoader{
+		next:       next,
+		precision:  precision,
+		ttl:        ttl,
+		staleTTL:   staleTTL,
+		now:        time.Now,
+		maxEntries: cacheMaxEntries,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+func (l *CachedLoader) Stats() CacheStats {
+	return CacheStats{
+		Hits:      l.hits.Load(),
+		StaleHits: l.staleHits.Load(),
+		Misses:    l.misses.Load(),
+	}
+}
+
+// Warmup warms up the wrapped loader.
+func (l *CachedLoader) Warmup(ctx context.Context) error {
+	return provider.Warmup(ctx, l.next)
+}
+
+func (l *CachedLoader) Load(ctx context.Context, lat, lng string) (Weather, error) {
+	span := trace.SpanFromContext(ctx)
+	key, ok := l.key(lat, lng)
+	if !ok {
+		l.misses.Add(1)
+		span.SetAttributes(attribute.Bool("weather.cache.hit", false))
+		return l.next.Load(ctx, lat, lng)
+	}
+
+	l.mu.Lock()
+	elem, cached := l.entries[key]
+	var entry *weatherEntry
+	if cached {
+		entry = elem.Value.(*weatherEntry)
+		l.lru.MoveToFront(elem)
+		age := l.now().Sub(entry.storedAt)
+		if age < l.ttl {
+			l.mu.Unlock()
+			l.hits.Add(1)
+			span.SetAttributes(attribute.Bool("weather.cache.hit", true))
+			return entry.weather, nil
+		}
+
+		if age < l.ttl+l.staleTTL {
+			if !entry.refreshing {
+				entry.refreshing = true
+				go l.refresh(context.WithoutCancel(ctx), key, lat, lng)
+			}
+			l.mu.Unlock()
+			l.staleHits.Add(1)
+			span.SetAttributes(
+				attribute.Bool("weather.cache.hit", true),
+				attribute.Bool("weather.cache.stale", true),
+			)
+			return entry.weather, nil
+		}
+	}
+	l.mu.Unlock()
+
+	l.misses.Add(1)
+	span.SetAttributes(attribute.Bool("weather.cache.hit", false))
+
+	w, err := l.next.Load(ctx, lat, lng)
+	if err != nil {
+		if cached && errors.Is(err, ErrQuotaExhausted) {
+			span.SetAttributes(attribute.Bool("weather.cache.expired", true))
+			return entry.weather, nil
+		}
+		return Weather{}, err
+	}
+	l.set(key, w)
+	return w, nil
+}
+
+func (l *CachedLoader) refresh(ctx context.Context, key, lat, lng string) {
+	ctx, cancel := context.WithTimeout(ctx, cacheRefreshTimeout)
+	defer cancel()
+
+	w, err := l.next.Load(ctx, lat, lng)
+	if err != nil {
+		l.mu.Lock()
+		if elem, ok := l.entries[key]; ok {
+			elem.Value.(*weatherEntry).refreshing = false
+		}
+		l.mu.Unlock()
+		return
+	}
+	l.set(key, w)
+}
+
+func (l *CachedLoader) set(key string, w Weather) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry := &weatherEntry{key: key, weather: w, storedAt: l.now()}
+	if elem, ok := l.entries[key]; ok {
+		elem.Value = entry
+		l.lru.MoveToFront(elem)
+		return
+	}
+
+	l.entries[key] = l.lru.PushFront(entry)
+	for l.lru.Len() > l.maxEntries {
+		oldest := l.lru.Back()
+		l.lru.Remove(oldest)
+		delete(l.entries, oldest.Value.(*weatherEntry).key)
+	}
+}
+
+// key rounds the coordinates to the loader precision. Coordinates that
+// can not be parsed are not cached.
+func (l *CachedLoader) key(lat, lng string) (string, bool) {
+	la, err := strconv.ParseFloat(lat, 64)
+	if err != nil {
+		return "", false
+	}
+	ln, err := strconv.ParseFloat(lng, 64)
+	if err != nil {
+		return "", false
+	}
+
+	scale := math.Pow10(l.precision)
+	la = math.Round(la*scale) / scale
+	ln = math.Round(ln*scale) / scale
+	return strconv.FormatFloat(la, 'f', l.precision, 64) + "," + strconv.FormatFloat(ln, 'f', l.precision, 64), true
+}
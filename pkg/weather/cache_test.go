@@ -0,0 +1,167 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCachedLoader_Load(t *testing.T) {
+	t.Run("CachedLoader should share entries between nearby coordinates", func(t *testing.T) {
+		next := succeedingLoader("next", 20)
+		sut := NewCachedLoader(next, 2, time.Minute, time.Minute)
+		ctx := context.Background()
+
+		_, _ = sut.Load(ctx, "-22.09967", "-43.2116")
+		got, err := sut.Load(ctx, "-22.1012", "-43.2089")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.TempC != 20 {
+			t.Errorf("expected TempC to be 20, got %f instead", got.TempC)
+		}
+
+		if next.calls != 1 {
+			t.Errorf("expected 1 upstream call, got %d instead", next.calls)
+		}
+
+		stats := sut.Stats()
+		if stats.Hits != 1 || stats.Misses != 1 {
+			t.Errorf("expected 1 hit and 1 miss, got %+v instead", stats)
+		}
+	})
+
+	t.Run("CachedLoader should return stale readings while refreshing in the background", func(t *testing.T) {
+		var calls atomic.Int32
+		refreshed := make(chan struct{})
+		next := &fakeLoader{
+			name: "next",
+			load: func(ctx context.Context, lat, lng string) (Weather, error) {
+				n := calls.Add(1)
+				if n > 1 {
+					defer close(refreshed)
+				}
+				return Weather{TempC: float64(n)}, nil
+			},
+		}
+		sut := NewCachedLoader(next, 2, time.Minute, time.Minute)
+		now := time.Now()
+		sut.now = func() time.Time { return now }
+		ctx := context.Background()
+
+		_, _ = sut.Load(ctx, "-22.09967", "-43.2116")
+		now = now.Add(90 * time.Second)
+		got, _ := sut.Load(ctx, "-22.09967", "-43.2116")
+
+		if got.TempC != 1 {
+			t.Errorf("expected stale TempC to be 1, got %f instead", got.TempC)
+		}
+
+		select {
+		case <-refreshed:
+		case <-time.After(time.Second):
+			t.Fatalf("expected a background refresh")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		got, _ = sut.Load(ctx, "-22.09967", "-43.2116")
+
+		if got.TempC != 2 {
+			t.Errorf("expected refreshed TempC to be 2, got %f instead", got.TempC)
+		}
+
+		stats := sut.Stats()
+		if stats.StaleHits != 1 {
+			t.Errorf("expected 1 stale hit, got %+v instead", stats)
+		}
+	})
+
+	t.Run("CachedLoader should reload entries past the stale window", func(t *testing.T) {
+		next := succeedingLoader("next", 20)
+		sut := NewCachedLoader(next, 2, time.Minute, time.Minute)
+		now := time.Now()
+		sut.now = func() time.Time { return now }
+		ctx := context.Background()
+
+		_, _ = sut.Load(ctx, "-22.09967", "-43.2116")
+		now = now.Add(2 * time.Minute)
+		_, _ = sut.Load(ctx, "-22.09967", "-43.2116")
+
+		if next.calls != 2 {
+			t.Errorf("expected 2 upstream calls, got %d instead", next.calls)
+		}
+	})
+
+	t.Run("CachedLoader should not cache errors", func(t *testing.T) {
+		next := failingLoader("next", ErrServiceUnavailable)
+		sut := NewCachedLoader(next, 2, time.Minute, time.Minute)
+		ctx := context.Background()
+
+		_, _ = sut.Load(ctx, "-22.09967", "-43.2116")
+		_, err := sut.Load(ctx, "-22.09967", "-43.2116")
+
+		if !errors.Is(err, ErrServiceUnavailable) {
+			t.Errorf("expected service unavailable error, got '%v' instead", err)
+		}
+
+		if next.calls != 2 {
+			t.Errorf("expected 2 upstream calls, got %d instead", next.calls)
+		}
+	})
+
+	t.Run("CachedLoader should return expired readings once the quota is exhausted", func(t *testing.T) {
+		exhausted := false
+		next := &fakeLoader{
+			name: "next",
+			load: func(ctx context.Context, lat, lng string) (Weather, error) {
+				if exhausted {
+					return Weather{}, ErrQuotaExhausted
+				}
+				return Weather{TempC: 20}, nil
+			},
+		}
+		sut := NewCachedLoader(next, 2, time.Minute, time.Minute)
+		now := time.Now()
+		sut.now = func() time.Time { return now }
+		ctx := context.Background()
+
+		_, _ = sut.Load(ctx, "-22.09967", "-43.2116")
+		now = now.Add(time.Hour)
+		exhausted = true
+		got, err := sut.Load(ctx, "-22.09967", "-43.2116")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.TempC != 20 {
+			t.Errorf("expected expired TempC to be 20, got %f instead", got.TempC)
+		}
+	})
+	t.Run("CachedLoader should drop the least recently used entry once full", func(t *testing.T) {
+		next := succeedingLoader("next", 20)
+		sut := NewCachedLoader(next, 2, time.Minute, time.Minute)
+		sut.maxEntries = 2
+		ctx := context.Background()
+
+		_, _ = sut.Load(ctx, "1", "1")
+		_, _ = sut.Load(ctx, "2", "2")
+		_, _ = sut.Load(ctx, "1", "1")
+		_, _ = sut.Load(ctx, "3", "3")
+
+		if len(sut.entries) != 2 {
+			t.Errorf("expected 2 entries, got %d instead", len(sut.entries))
+		}
+
+		_, _ = sut.Load(ctx, "1", "1")
+		_, _ = sut.Load(ctx, "2", "2")
+
+		if next.calls != 4 {
+			t.Errorf("expected only the dropped entry to be reloaded, got %d upstream calls instead", next.calls)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/provider"
+)
+
+// FallbackLoader tries each loader in order until one of them answers.
+// Unavailable or unauthorized providers and transport errors move on to
+// the next loader, while ErrInvalidLocation is returned right away.
+type FallbackLoader struct {
+	tracer  trace.Tracer
+	loaders []Loader
+}
+
+var _ Loader = &FallbackLoader{}
+
+func NewFallbackLoader(tracer trace.Tracer, loaders ...Loader) *FallbackLoader {
+	return &FallbackLoader{
+		tracer:  tracer,
+		loaders: loaders,
+	}
+}
+
+// Warmup warms up every wrapped loader.
+func (l *FallbackLoader) Warmup(ctx context.Context) error {
+	return provider.WarmupAll(ctx, l.loaders)
+}
+
+func (l *FallbackLoader) Load(ctx context.Context, lat, lng string) (Weather, error) {
+	errs := make([]error, 0, len(l.loaders))
+	for i, loader := range l.loaders {
+		name := provider.Name(loader)
+		attemptCtx, span := l.tracer.Start(ctx, "weather-provider", trace.WithAttributes(
+			attribute.String("weather.provider", name),
+			attribute.Int("weather.attempt", i+1),
+		))
+
+		w, err := loader.Load(attemptCtx, lat, lng)
+		endAttemptSpan(span, err)
+		if err == nil {
+			if w.Service == "" {
+				w.Service = name
+			}
+			return w, nil
+		}
+
+		if !shouldFallThrough(ctx, err) {
+			return Weather{}, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+
+	return Weather{}, fmt.Errorf("%w: all providers failed: %w", ErrServiceUnavailable, errors.Join(errs...))
+}
+
+// shouldFallThrough reports whether a failed attempt is worth retrying
+// with another provider.
+func shouldFallThrough(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return !errors.Is(err, ErrInvalidLocation)
+}
+
+func attemptOutcome(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, ErrInvalidLocation):
+		return "invalid_location"
+	case errors.Is(err, ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, ErrQuotaExhausted):
+		return "quota_exhausted"
+	case errors.Is(err, ErrServiceUnavailable):
+		return "unavailable"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	default:
+		return "error"
+	}
+}
+
+func endAttemptSpan(span trace.Span, err error) {
+	provider.EndAttempt(span, attribute.String("weather.outcome", attemptOutcome(err)), err, "weather provider failed")
+}
@@ -0,0 +1,137 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type fakeLoader struct {
+	name  string
+	calls int
+	load  func(ctx context.Context, lat, lng string) (Weather, error)
+}
+
+func (l *fakeLoader) Name() string {
+	return l.name
+}
+
+func (l *fakeLoader) Load(ctx context.Context, lat, lng string) (Weather, error) {
+	l.calls++
+	return l.load(ctx, lat, lng)
+}
+
+func failingLoader(name string, err error) *fakeLoader {
+	return &fakeLoader{
+		name: name,
+		load: func(ctx context.Context, lat, lng string) (Weather, error) {
+			return Weather{}, err
+		},
+	}
+}
+
+func succeedingLoader(name string, tempC float64) *fakeLoader {
+	return &fakeLoader{
+		name: name,
+		load: func(ctx context.Context, lat, lng string) (Weather, error) {
+			return Weather{TempC: tempC}, nil
+		},
+	}
+}
+
+func TestFallbackLoader_Load(t *testing.T) {
+	tracer := noop.NewTracerProvider().Tracer("test")
+
+	t.Run("FallbackLoader should fall through unavailable and unauthorized providers", func(t *testing.T) {
+		sut := NewFallbackLoader(tracer,
+			failingLoader("first", ErrServiceUnavailable),
+			failingLoader("second", ErrUnauthorized),
+			failingLoader("third", errors.New("connection refused")),
+			succeedingLoader("fourth", 20),
+		)
+
+		got, err := sut.Load(context.Background(), "-22.09967", "-43.2116")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.Service != "fourth" {
+			t.Errorf("expected service to be fourth, got '%s' instead", got.Service)
+		}
+	})
+
+	t.Run("FallbackLoader should stop on invalid location", func(t *testing.T) {
+		second := succeedingLoader("second", 20)
+		sut := NewFallbackLoader(tracer, failingLoader("first", ErrInvalidLocation), second)
+
+		_, err := sut.Load(context.Background(), "", "")
+
+		if !errors.Is(err, ErrInvalidLocation) {
+			t.Errorf("expected invalid location error, got '%v' instead", err)
+		}
+
+		if second.calls != 0 {
+			t.Errorf("expected second loader not to be called, got %d calls instead", second.calls)
+		}
+	})
+
+	t.Run("FallbackLoader should return service unavailable when every provider fails", func(t *testing.T) {
+		sut := NewFallbackLoader(tracer,
+			failingLoader("first", ErrUnauthorized),
+			failingLoader("second", ErrServiceUnavailable),
+		)
+
+		_, err := sut.Load(context.Background(), "-22.09967", "-43.2116")
+
+		if !errors.Is(err, ErrServiceUnavailable) {
+			t.Errorf("expected service unavailable error, got '%v' instead", err)
+		}
+
+		if !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("expected error to wrap the unauthorized error, got '%v' instead", err)
+		}
+	})
+
+	t.Run("FallbackLoader should record provider and outcome on each attempt span", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		tracer := tp.Tracer("test")
+		ctx, parent := tracer.Start(context.Background(), "weather-loader")
+		sut := NewFallbackLoader(tracer,
+			failingLoader("first", ErrUnauthorized),
+			succeedingLoader("second", 20),
+		)
+
+		_, _ = sut.Load(ctx, "-22.09967", "-43.2116")
+		parent.End()
+
+		spans := recorder.Ended()
+		if len(spans) != 3 {
+			t.Fatalf("expected 3 spans, got %d instead", len(spans))
+		}
+
+		want := []struct{ provider, outcome string }{
+			{provider: "first", outcome: "unauthorized"},
+			{provider: "second", outcome: "success"},
+		}
+		for i, span := range spans[:2] {
+			if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+				t.Errorf("(%d): expected span to be a child of weather-loader", i)
+			}
+
+			attrs := attribute.NewSet(span.Attributes()...)
+			if v, _ := attrs.Value("weather.provider"); v.AsString() != want[i].provider {
+				t.Errorf("(%d): expected provider to be %s, got '%s' instead", i, want[i].provider, v.AsString())
+			}
+			if v, _ := attrs.Value("weather.outcome"); v.AsString() != want[i].outcome {
+				t.Errorf("(%d): expected outcome to be %s, got '%s' instead", i, want[i].outcome, v.AsString())
+			}
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/httpclient"
+)
+
+const openMeteoBaseURL = "https://api.open-meteo.com"
+
+type openMeteoResponse struct {
+	Current struct {
+		Temperature float64 `json:"temperature_2m"`
+	} `json:"current"`
+}
+
+// OpenMeteoLoader loads the current temperature from Open-Meteo, which
+// does not require an API key.
+type OpenMeteoLoader struct {
+	baseURL string
+	client  *http.Client
+}
+
+var _ Loader = &OpenMeteoLoader{}
+
+func NewOpenMeteoLoader(opts ...Option) (*OpenMeteoLoader, error) {
+	c, err := newConfig(openMeteoBaseURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &OpenMeteoLoader{
+		baseURL: c.BaseURL,
+		client:  c.Client,
+	}, nil
+}
+
+func (l *OpenMeteoLoader) Name() string {
+	return "OpenMeteo"
+}
+
+func (l *OpenMeteoLoader) Warmup(ctx context.Context) error {
+	return httpclient.Warmup(ctx, l.client, l.baseURL)
+}
+
+func (l *OpenMeteoLoader) Load(ctx context.Context, lat, lng string) (Weather, error) {
+	if _, err := strconv.ParseFloat(lat, 64); err != nil {
+		return Weather{}, ErrInvalidLocation
+	}
+	if _, err := strconv.ParseFloat(lng, 64); err != nil {
+		return Weather{}, ErrInvalidLocation
+	}
+
+	query := url.Values{}
+	query.Set("latitude", lat)
+	query.Set("longitude", lng)
+	query.Set("current", "temperature_2m")
+
+	url := fmt.Sprintf("%s/v1/forecast?%s", l.baseURL, query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Weather{}, err
+	}
+
+	res, err := l.client.Do(req)
+	if err != nil {
+		return Weather{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 && res.StatusCode < 500 {
+		return Weather{}, ErrInvalidLocation
+	}
+
+	if res.StatusCode != 200 {
+		return Weather{}, ErrServiceUnavailable
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return Weather{}, err
+	}
+
+	var b openMeteoResponse
+	err = json.Unmarshal(body, &b)
+	if err != nil {
+		return Weather{}, err
+	}
+
+	c := Weather{
+		TempC:   b.Current.Temperature,
+		TempF:   CelsiusToFahrenheit(b.Current.Temperature),
+		TempK:   CelsiusToKelvin(b.Current.Temperature),
+		Service: l.Name(),
+	}
+
+	return c, nil
+}
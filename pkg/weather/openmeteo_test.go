@@ -0,0 +1,102 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newOpenMeteoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/forecast", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("current") != "temperature_2m" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": true, "reason": "missing current parameter"}`))
+			return
+		}
+
+		switch query.Get("latitude") {
+		case "-22.09967":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"current": {"time": "2024-06-01T12:00", "interval": 900, "temperature_2m": 25.5}}`))
+		case "-91":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": true, "reason": "Latitude must be in range of -90 to 90°."}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOpenMeteoLoader_Load(t *testing.T) {
+	srv := newOpenMeteoServer(t)
+
+	t.Run("OpenMeteo should return error on malformed latitude and longitude", func(t *testing.T) {
+		sut, _ := NewOpenMeteoLoader(WithBaseURL(srv.URL))
+		ctx := context.Background()
+
+		_, err := sut.Load(ctx, "", "")
+
+		if !errors.Is(err, ErrInvalidLocation) {
+			t.Errorf("expected invalid location error, got '%v' instead", err)
+		}
+	})
+
+	t.Run("OpenMeteo should return error on out of range location", func(t *testing.T) {
+		sut, _ := NewOpenMeteoLoader(WithBaseURL(srv.URL))
+		ctx := context.Background()
+
+		_, err := sut.Load(ctx, "-91", "0")
+
+		if !errors.Is(err, ErrInvalidLocation) {
+			t.Errorf("expected invalid location error, got '%v' instead", err)
+		}
+	})
+
+	t.Run("OpenMeteo should return service unavailable error on server failure", func(t *testing.T) {
+		sut, _ := NewOpenMeteoLoader(WithBaseURL(srv.URL))
+		ctx := context.Background()
+
+		_, err := sut.Load(ctx, "0", "0")
+
+		if !errors.Is(err, ErrServiceUnavailable) {
+			t.Errorf("expected service unavailable error, got '%v' instead", err)
+		}
+	})
+
+	t.Run("OpenMeteo should return temperature on valid location", func(t *testing.T) {
+		sut, _ := NewOpenMeteoLoader(WithBaseURL(srv.URL))
+		ctx := context.Background()
+
+		got, err := sut.Load(ctx, "-22.09967", "-43.2116")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.TempC != 25.5 {
+			t.Errorf("expected TempC to be 25.5, got %f instead", got.TempC)
+		}
+
+		if got.TempK != CelsiusToKelvin(25.5) {
+			t.Errorf("expected TempK to be %f, got %f instead", CelsiusToKelvin(25.5), got.TempK)
+		}
+
+		if got.TempF != CelsiusToFahrenheit(25.5) {
+			t.Errorf("expected TempF to be %f, got %f instead", CelsiusToFahrenheit(25.5), got.TempF)
+		}
+
+		if got.Service != "OpenMeteo" {
+			t.Errorf("expected service to be OpenMeteo, got '%s' instead", got.Service)
+		}
+	})
+}
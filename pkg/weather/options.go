@@ -0,0 +1,132 @@
+package weather
+
+import (
+	"crypto/tls"
+	"net/http"
+	"regexp"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/httpclient"
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/retry"
+)
+
+type config struct {
+	httpclient.Config
+	quota QuotaSettings
+}
+
+// Option configures the HTTP based loaders of this package.
+type Option func(*config) error
+
+// WithBaseURL overrides the provider base URL, useful to point a loader
+// at a local stand-in during tests.
+func WithBaseURL(baseURL string) Option {
+	return func(c *config) error {
+		return c.SetBaseURL(baseURL)
+	}
+}
+
+// WithHTTPClient sets the client used to reach the provider. It can not
+// be combined with the TLS and retry options, which only apply to the
+// default client.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) error {
+		return c.SetHTTPClient(client)
+	}
+}
+
+// WithRetryPolicy sets how the default client retries transient provider
+// failures, replacing retry.DefaultPolicy.
+func WithRetryPolicy(policy retry.Policy) Option {
+	return func(c *config) error {
+		c.SetRetryPolicy(policy)
+		return nil
+	}
+}
+
+// WithQuota sets the monthly call cap of each provider key. Only the
+// WeatherAPILoader, whose keys are capped, uses it.
+func WithQuota(settings QuotaSettings) Option {
+	return func(c *config) error {
+		c.quota = settings
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the default client.
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(c *config) error {
+		return c.SetTLSConfig(tlsConfig)
+	}
+}
+
+// WithCABundle trusts the PEM encoded certificates found at path, on top
+// of the system roots, when verifying the provider certificate.
+func WithCABundle(path string) Option {
+	return func(c *config) error {
+		return c.LoadCABundle(path)
+	}
+}
+
+func newConfig(baseURL string, opts []Option) (config, error) {
+	c := config{Config: httpclient.Config{BaseURL: baseURL}}
+	for _, opt := range opts {
+		if err := opt(&c); err != nil {
+			return config{}, err
+		}
+	}
+	c.WrapTransport(func(base http.RoundTripper) http.RoundTripper {
+		return redactedTransport{base}
+	})
+	if err := c.Build(); err != nil {
+		return config{}, err
+	}
+	return c, nil
+}
+
+// redactedTransport overwrites the URL recorded on the client span so the
+// API key sent in the query string never reaches the trace backend. The
+// errors it returns are redacted too, as the retry events record them.
+type redactedTransport struct {
+	base http.RoundTripper
+}
+
+func (t redactedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if query := req.URL.Query(); query.Has("key") {
+		query.Set("key", "REDACTED")
+		redacted := *req.URL
+		redacted.RawQuery = query.Encode()
+		trace.SpanFromContext(req.Context()).SetAttributes(
+			attribute.String("http.url", redacted.String()),
+			attribute.String("url.full", redacted.String()),
+		)
+	}
+	resp, err := t.base.RoundTrip(req)
+	return resp, redactError(err)
+}
+
+var keyParam = regexp.MustCompile(`([?&]key=)[^&\s"]*`)
+
+// redactedError hides the API key from the text of an error, such as the
+// *url.Error returned by http.Client, which quotes the request URL. The
+// wrapped error is still reachable through errors.Is and errors.As.
+type redactedError struct {
+	err error
+}
+
+func redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return redactedError{err}
+}
+
+func (e redactedError) Error() string {
+	return keyParam.ReplaceAllString(e.err.Error(), "${1}REDACTED")
+}
+
+func (e redactedError) Unwrap() error {
+	return e.err
+}
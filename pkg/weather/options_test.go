@@ -0,0 +1,151 @@
+package weather
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/allanmaral/go-expert-otel-challenge/pkg/retry"
+)
+
+func newWeatherAPITLSServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"current":{"temp_c":25.5}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func writeCABundle(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(path, bundle, 0o600); err != nil {
+		t.Fatalf("could not write ca bundle: %v", err)
+	}
+	return path
+}
+
+func TestOptions(t *testing.T) {
+	srv := newWeatherAPITLSServer(t)
+
+	t.Run("Loaders should verify provider certificates by default", func(t *testing.T) {
+		sut, _ := NewWeatherAPILoader("key", WithBaseURL(srv.URL))
+
+		_, err := sut.Load(context.Background(), "-22.09967", "-43.2116")
+
+		if err == nil {
+			t.Errorf("expected certificate verification error, got nil instead")
+		}
+	})
+
+	t.Run("WithCABundle should trust the certificates in the bundle", func(t *testing.T) {
+		sut, err := NewWeatherAPILoader("key", WithBaseURL(srv.URL), WithCABundle(writeCABundle(t, srv)))
+		if err != nil {
+			t.Fatalf("expected error to be nil, got '%v' instead", err)
+		}
+
+		got, err := sut.Load(context.Background(), "-22.09967", "-43.2116")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.TempC != 25.5 {
+			t.Errorf("expected TempC to be 25.5, got %f instead", got.TempC)
+		}
+	})
+
+	t.Run("WithTLSConfig should configure the default client", func(t *testing.T) {
+		sut, _ := NewWeatherAPILoader("key", WithBaseURL(srv.URL), WithTLSConfig(&tls.Config{InsecureSkipVerify: true}))
+
+		_, err := sut.Load(context.Background(), "-22.09967", "-43.2116")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+	})
+
+	t.Run("TLS options should not be combined with a custom client", func(t *testing.T) {
+		_, err := NewWeatherAPILoader("key", WithHTTPClient(srv.Client()), WithTLSConfig(&tls.Config{}))
+
+		if err == nil {
+			t.Errorf("expected error on conflicting options, got nil instead")
+		}
+	})
+}
+
+func TestRedactedTransport(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	leaks := func(t *testing.T) {
+		t.Helper()
+		for _, span := range recorder.Ended() {
+			attrs := span.Attributes()
+			for _, event := range span.Events() {
+				attrs = append(attrs, event.Attributes...)
+			}
+			for _, attr := range attrs {
+				if strings.Contains(attr.Value.Emit(), "secret") {
+					t.Errorf("expected %s to be redacted, got '%v' instead", attr.Key, attr.Value.Emit())
+				}
+			}
+		}
+	}
+	policy := retry.Policy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	t.Run("RedactedTransport should hide the API key from the client span", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"current":{"temp_c":25.5}}`))
+		}))
+		t.Cleanup(srv.Close)
+		sut, _ := NewWeatherAPILoader("secret", WithBaseURL(srv.URL), WithRetryPolicy(policy))
+
+		_, err := sut.Load(context.Background(), "-22.09967", "-43.2116")
+
+		if err != nil {
+			t.Fatalf("expected error to be nil, got '%v' instead", err)
+		}
+
+		leaks(t)
+	})
+
+	t.Run("RedactedTransport should hide the API key from errors and retry events", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+		sut, _ := NewWeatherAPILoader("secret", WithBaseURL(srv.URL), WithRetryPolicy(policy))
+
+		_, err := sut.Load(context.Background(), "-22.09967", "-43.2116")
+
+		if err == nil || strings.Contains(err.Error(), "secret") || !strings.Contains(err.Error(), "key=REDACTED") {
+			t.Errorf("expected a redacted error, got '%v' instead", err)
+		}
+
+		var urlErr *url.Error
+		if !errors.As(err, &urlErr) {
+			t.Errorf("expected the *url.Error to stay reachable, got '%T' instead", err)
+		}
+
+		leaks(t)
+	})
+}
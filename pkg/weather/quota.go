@@ -0,0 +1,242 @@
+package weather
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExhausted is returned when every key of a provider used up its
+// quota, or got too close to its cap, for the current period. It wraps
+// ErrServiceUnavailable so a FallbackLoader moves on to the next provider.
+var ErrQuotaExhausted = fmt.Errorf("%w: quota exhausted", ErrServiceUnavailable)
+
+// quotaRemainingHeaders are the response headers read, in order, for the
+// number of calls a key has left in the period.
+var quotaRemainingHeaders = []string{"X-RateLimit-Remaining", "RateLimit-Remaining"}
+
+// quotaRejectCooldown is how long a key the provider refused is left
+// aside before it is tried again, so a passing provider glitch or a
+// renewed key does not put the key out of use for the rest of the month.
+const quotaRejectCooldown = 5 * time.Minute
+
+// QuotaSettings describes the call cap of each provider key.
+type QuotaSettings struct {
+	// Limit is how many calls each key may make per calendar month, in
+	// UTC. Zero means the cap is unknown, so only the quota headers and
+	// the keys rejected by the provider are taken into account.
+	Limit int64
+	// Reserve is how many calls short of the cap a key stops being used,
+	// leaving room for the retries that are not counted and for other
+	// services sharing the key.
+	Reserve int64
+}
+
+// KeyUsage holds what a QuotaAccountant knows about a key in the current
+// period. Keys are identified by their position in the pool, never by
+// their value.
+type KeyUsage struct {
+	Key       string
+	Calls     int64
+	Remaining int64 // -1 when unknown
+	Exhausted bool
+	Rejected  bool
+}
+
+// QuotaAccountant counts the calls made with each key of a pool and picks
+// the key with the most calls left for the next one. Counts live in
+// memory and start over every calendar month; quota headers sent by the
+// provider correct them after a restart.
+type QuotaAccountant struct {
+	settings QuotaSettings
+	now      func() time.Time
+
+	mu     sync.Mutex
+	period time.Time
+	keys   []*quotaKey
+}
+
+type quotaKey struct {
+	label         string
+	value         string
+	calls         int64
+	remaining     int64
+	exhausted     bool
+	rejectedUntil time.Time
+}
+
+func NewQuotaAccountant(keys []string, settings QuotaSettings) (*QuotaAccountant, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one key is required")
+	}
+	if settings.Limit < 0 || settings.Reserve < 0 {
+		return nil, fmt.Errorf("quota limit and reserve must not be negative")
+	}
+	if settings.Limit > 0 && settings.Reserve >= settings.Limit {
+		return nil, fmt.Errorf("quota reserve must be lower than the limit")
+	}
+
+	a := &QuotaAccountant{settings: settings, now: time.Now}
+	for i, key := range keys {
+		a.keys = append(a.keys, &quotaKey{
+			label:     "key-" + strconv.Itoa(i+1),
+			value:     key,
+			remaining: -1,
+		})
+	}
+	a.period = a.currentPeriod()
+	return a, nil
+}
+
+// SplitKeys splits a comma separated list of keys, dropping blanks.
+func SplitKeys(s string) []string {
+	var keys []string
+	for _, key := range strings.Split(s, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Usage returns a snapshot of every key in the pool.
+func (a *QuotaAccountant) Usage() []KeyUsage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rollover()
+
+	usage := make([]KeyUsage, 0, len(a.keys))
+	for _, k := range a.keys {
+		usage = append(usage, KeyUsage{
+			Key:       k.label,
+			Calls:     k.calls,
+			Remaining: k.remaining,
+			Exhausted: k.exhausted || !a.usable(k),
+			Rejected:  a.rejected(k),
+		})
+	}
+	return usage
+}
+
+// acquire picks the key with the most calls left and counts the call
+// against it. It fails with ErrQuotaExhausted when no key has calls left,
+// or with ErrUnauthorized when the provider rejected every key.
+func (a *QuotaAccountant) acquire() (*quotaKey, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rollover()
+
+	var best *quotaKey
+	var bestLeft int64
+	exhausted := false
+	for _, k := range a.keys {
+		if a.rejected(k) {
+			continue
+		}
+		if k.exhausted || !a.usable(k) {
+			exhausted = true
+			continue
+		}
+		if left := a.left(k); best == nil || left > bestLeft {
+			best, bestLeft = k, left
+		}
+	}
+
+	if best == nil {
+		if exhausted {
+			return nil, ErrQuotaExhausted
+		}
+		return nil, ErrUnauthorized
+	}
+	best.calls++
+	if best.remaining > 0 {
+		best.remaining--
+	}
+	return best, nil
+}
+
+// record updates the key with the quota headers of a provider response.
+func (a *QuotaAccountant) record(k *quotaKey, h http.Header) {
+	for _, name := range quotaRemainingHeaders {
+		remaining, err := strconv.ParseInt(h.Get(name), 10, 64)
+		if err != nil || remaining < 0 {
+			continue
+		}
+		a.mu.Lock()
+		k.remaining = remaining
+		a.mu.Unlock()
+		return
+	}
+}
+
+// exhaust stops using the key until the next period because the provider
+// reported its quota as used up.
+func (a *QuotaAccountant) exhaust(k *quotaKey) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	k.exhausted = true
+}
+
+// reject stops using a key the provider refused for quotaRejectCooldown.
+func (a *QuotaAccountant) reject(k *quotaKey) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	k.rejectedUntil = a.now().Add(quotaRejectCooldown)
+}
+
+// rejected reports whether the key is still left aside after the provider
+// refused it. Callers must hold a.mu.
+func (a *QuotaAccountant) rejected(k *quotaKey) bool {
+	return a.now().Before(k.rejectedUntil)
+}
+
+// usable reports whether the key is still above its reserve. Callers must
+// hold a.mu.
+func (a *QuotaAccountant) usable(k *quotaKey) bool {
+	if a.settings.Limit > 0 && k.calls >= a.settings.Limit-a.settings.Reserve {
+		return false
+	}
+	if k.remaining >= 0 && k.remaining <= a.settings.Reserve {
+		return false
+	}
+	return true
+}
+
+// left estimates how many calls the key has left. With no cap and no
+// headers, the least used key wins. Callers must hold a.mu.
+func (a *QuotaAccountant) left(k *quotaKey) int64 {
+	switch {
+	case a.settings.Limit > 0 && k.remaining >= 0:
+		return min(a.settings.Limit-k.calls, k.remaining)
+	case a.settings.Limit > 0:
+		return a.settings.Limit - k.calls
+	case k.remaining >= 0:
+		return k.remaining
+	default:
+		return -k.calls
+	}
+}
+
+// rollover starts the counts over once a new period begins. Callers must
+// hold a.mu.
+func (a *QuotaAccountant) rollover() {
+	period := a.currentPeriod()
+	if !period.After(a.period) {
+		return
+	}
+	a.period = period
+	for _, k := range a.keys {
+		k.calls = 0
+		k.remaining = -1
+		k.exhausted = false
+		k.rejectedUntil = time.Time{}
+	}
+}
+
+func (a *QuotaAccountant) currentPeriod() time.Time {
+	now := a.now().UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
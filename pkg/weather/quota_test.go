@@ -0,0 +1,181 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// newQuotaServer answers like WeatherAPI, rejecting the keys found in
+// exceeded with the monthly quota error and counting the calls per key.
+func newQuotaServer(t *testing.T, exceeded ...string) (*httptest.Server, map[string]int) {
+	t.Helper()
+
+	calls := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("key")
+		calls[key]++
+		w.Header().Set("Content-Type", "application/json")
+		for _, k := range exceeded {
+			if k == key {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"error":{"code":2007,"message":"API key has exceeded calls per month quota."}}`))
+				return
+			}
+		}
+		w.Write([]byte(`{"current":{"temp_c":25.5}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, calls
+}
+
+func TestQuotaAccountant(t *testing.T) {
+	t.Run("QuotaAccountant should spread calls across the key pool", func(t *testing.T) {
+		sut, _ := NewQuotaAccountant([]string{"a", "b"}, QuotaSettings{Limit: 10})
+
+		for range 4 {
+			_, _ = sut.acquire()
+		}
+
+		for _, usage := range sut.Usage() {
+			if usage.Calls != 2 {
+				t.Errorf("expected 2 calls on %s, got %d instead", usage.Key, usage.Calls)
+			}
+		}
+	})
+
+	t.Run("QuotaAccountant should stop before the reserve is reached", func(t *testing.T) {
+		sut, _ := NewQuotaAccountant([]string{"a"}, QuotaSettings{Limit: 3, Reserve: 1})
+
+		_, _ = sut.acquire()
+		_, _ = sut.acquire()
+		_, err := sut.acquire()
+
+		if !errors.Is(err, ErrQuotaExhausted) || !errors.Is(err, ErrServiceUnavailable) {
+			t.Errorf("expected quota exhausted error, got '%v' instead", err)
+		}
+	})
+
+	t.Run("QuotaAccountant should trust the remaining calls sent by the provider", func(t *testing.T) {
+		sut, _ := NewQuotaAccountant([]string{"a", "b"}, QuotaSettings{})
+
+		key, _ := sut.acquire()
+		sut.record(key, http.Header{"X-Ratelimit-Remaining": []string{"0"}})
+		_, _ = sut.acquire()
+		_, err := sut.acquire()
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		usage := sut.Usage()
+		if usage[0].Calls != 1 || !usage[0].Exhausted || usage[1].Calls != 2 {
+			t.Errorf("expected the drained key to be skipped, got %+v instead", usage)
+		}
+	})
+
+	t.Run("QuotaAccountant should start over every month", func(t *testing.T) {
+		sut, _ := NewQuotaAccountant([]string{"a"}, QuotaSettings{Limit: 1})
+		now := time.Date(2024, time.January, 31, 23, 0, 0, 0, time.UTC)
+		sut.now = func() time.Time { return now }
+		sut.period = sut.currentPeriod()
+
+		_, _ = sut.acquire()
+		now = now.Add(2 * time.Hour)
+		_, err := sut.acquire()
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+	})
+
+	t.Run("QuotaAccountant should try a rejected key again after the cooldown", func(t *testing.T) {
+		sut, _ := NewQuotaAccountant([]string{"a"}, QuotaSettings{})
+		now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+		sut.now = func() time.Time { return now }
+
+		key, _ := sut.acquire()
+		sut.reject(key)
+		_, err := sut.acquire()
+
+		if !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("expected unauthorized error during the cooldown, got '%v' instead", err)
+		}
+
+		now = now.Add(quotaRejectCooldown)
+		_, err = sut.acquire()
+
+		if err != nil {
+			t.Errorf("expected the key to be tried again, got '%v' instead", err)
+		}
+	})
+
+	t.Run("NewQuotaAccountant should require a key", func(t *testing.T) {
+		_, err := NewQuotaAccountant(SplitKeys(" , "), QuotaSettings{})
+
+		if err == nil {
+			t.Errorf("expected error, got nil instead")
+		}
+	})
+}
+
+func TestWeatherAPILoader_Quota(t *testing.T) {
+	t.Run("WeatherAPI should move on to the next key once one is over quota", func(t *testing.T) {
+		srv, calls := newQuotaServer(t, "spent")
+		sut, _ := NewWeatherAPILoader("spent,fresh", WithBaseURL(srv.URL))
+
+		_, _ = sut.Load(context.Background(), "-22.09967", "-43.2116")
+		got, err := sut.Load(context.Background(), "-22.09967", "-43.2116")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.TempC != 25.5 {
+			t.Errorf("expected TempC to be 25.5, got %f instead", got.TempC)
+		}
+
+		if calls["spent"] != 1 || calls["fresh"] != 2 {
+			t.Errorf("expected 1 call with the spent key and 2 with the fresh one, got %v instead", calls)
+		}
+	})
+
+	t.Run("WeatherAPI should fail without calling the provider once every key is capped", func(t *testing.T) {
+		srv, calls := newQuotaServer(t)
+		sut, _ := NewWeatherAPILoader("a,b", WithBaseURL(srv.URL), WithQuota(QuotaSettings{Limit: 2, Reserve: 1}))
+
+		_, _ = sut.Load(context.Background(), "-22.09967", "-43.2116")
+		_, _ = sut.Load(context.Background(), "-22.09967", "-43.2116")
+		_, err := sut.Load(context.Background(), "-22.09967", "-43.2116")
+
+		if !errors.Is(err, ErrQuotaExhausted) {
+			t.Errorf("expected quota exhausted error, got '%v' instead", err)
+		}
+
+		if calls["a"] != 1 || calls["b"] != 1 {
+			t.Errorf("expected 1 call per key, got %v instead", calls)
+		}
+	})
+
+	t.Run("WeatherAPI should let the fallback take over once the quota is exhausted", func(t *testing.T) {
+		srv, _ := newQuotaServer(t, "a")
+		weatherAPI, _ := NewWeatherAPILoader("a", WithBaseURL(srv.URL))
+		sut := NewFallbackLoader(noop.NewTracerProvider().Tracer("test"), weatherAPI, succeedingLoader("up", 20))
+
+		_, _ = sut.Load(context.Background(), "-22.09967", "-43.2116")
+		got, err := sut.Load(context.Background(), "-22.09967", "-43.2116")
+
+		if err != nil {
+			t.Errorf("expected error to be nil, got '%v' instead", err)
+		}
+
+		if got.TempC != 20 {
+			t.Errorf("expected TempC to be 20, got %f instead", got.TempC)
+		}
+	})
+}
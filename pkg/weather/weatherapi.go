@@ -2,38 +2,107 @@ package weather
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/allanmaral/go-expert-otel-challenge/internal/httpclient"
 )
 
+const weatherAPIBaseURL = "https://api.weatherapi.com"
+
+// weatherAPIQuotaExceeded is the error code WeatherAPI answers with once a
+// key used up its monthly calls.
+const weatherAPIQuotaExceeded = 2007
+
 type weatherAPIResponse struct {
 	Current struct {
 		TempC float64 `json:"temp_c"`
 	} `json:"current"`
 }
 
+type weatherAPIError struct {
+	Error struct {
+		Code int `json:"code"`
+	} `json:"error"`
+}
+
+// WeatherAPILoader reads the current temperature from WeatherAPI. The
+// apikey may hold a comma separated pool of keys: each lookup uses the
+// key with the most calls left, a key the provider rejects is skipped
+// for a few minutes and ErrQuotaExhausted is returned before the last key
+// reaches its cap.
 type WeatherAPILoader struct {
-	apikey string
-	client *http.Client
+	quota   *QuotaAccountant
+	baseURL string
+	client  *http.Client
 }
 
 var _ Loader = &WeatherAPILoader{}
 
-func NewWeatherAPILoader(apikey string) *WeatherAPILoader {
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+func NewWeatherAPILoader(apikey string, opts ...Option) (*WeatherAPILoader, error) {
+	c, err := newConfig(weatherAPIBaseURL, opts)
+	if err != nil {
+		return nil, err
 	}
-	return &WeatherAPILoader{
-		apikey: apikey,
-		client: &http.Client{Transport: tr},
+	keys := SplitKeys(apikey)
+	if len(keys) == 0 {
+		// A missing key is left for the provider to reject.
+		keys = []string{""}
+	}
+	quota, err := NewQuotaAccountant(keys, c.quota)
+	if err != nil {
+		return nil, fmt.Errorf("weatherapi keys: %w", err)
 	}
+	return &WeatherAPILoader{
+		quota:   quota,
+		baseURL: c.BaseURL,
+		client:  c.Client,
+	}, nil
+}
+
+func (l *WeatherAPILoader) Name() string {
+	return "WeatherAPI"
+}
+
+// Quota returns the usage of every key in the pool.
+func (l *WeatherAPILoader) Quota() []KeyUsage {
+	return l.quota.Usage()
+}
+
+func (l *WeatherAPILoader) Warmup(ctx context.Context) error {
+	return httpclient.Warmup(ctx, l.client, l.baseURL)
 }
 
 func (l *WeatherAPILoader) Load(ctx context.Context, lat, lng string) (Weather, error) {
-	url := fmt.Sprintf("https://api.weatherapi.com/v1/current.json?key=%s&q=%s,%s&aqi=no", l.apikey, lat, lng)
+	var rejected error
+	for {
+		key, err := l.quota.acquire()
+		if err != nil {
+			if rejected != nil && errors.Is(err, ErrUnauthorized) {
+				return Weather{}, rejected
+			}
+			return Weather{}, err
+		}
+
+		w, err := l.load(ctx, key, lat, lng)
+		if !errors.Is(err, ErrUnauthorized) {
+			return w, err
+		}
+		rejected = err
+	}
+}
+
+func (l *WeatherAPILoader) load(ctx context.Context, key *quotaKey, lat, lng string) (Weather, error) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("weather.quota.key", key.label))
+
+	url := fmt.Sprintf("%s/v1/current.json?key=%s&q=%s,%s&aqi=no", l.baseURL, key.value, lat, lng)
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return Weather{}, err
@@ -43,12 +112,26 @@ func (l *WeatherAPILoader) Load(ctx context.Context, lat, lng string) (Weather,
 
 	res, err := l.client.Do(req)
 	if err != nil {
-		return Weather{}, err
+		return Weather{}, redactError(err)
 	}
 	defer res.Body.Close()
 
-	if res.StatusCode == 403 {
-		return Weather{}, ErrUnauthorized
+	l.quota.record(key, res.Header)
+
+	if res.StatusCode == 401 || res.StatusCode == 403 {
+		var e weatherAPIError
+		_ = json.NewDecoder(io.LimitReader(res.Body, 64<<10)).Decode(&e)
+		if e.Error.Code == weatherAPIQuotaExceeded {
+			l.quota.exhaust(key)
+		} else {
+			l.quota.reject(key)
+		}
+		span.AddEvent("weather.quota.key_rejected", trace.WithAttributes(
+			attribute.String("weather.quota.key", key.label),
+			attribute.Int("http.response.status_code", res.StatusCode),
+			attribute.Int("weatherapi.error.code", e.Error.Code),
+		))
+		return Weather{}, fmt.Errorf("%w: %s", ErrUnauthorized, key.label)
 	}
 
 	if res.StatusCode >= 400 && res.StatusCode < 500 {
@@ -74,7 +157,7 @@ func (l *WeatherAPILoader) Load(ctx context.Context, lat, lng string) (Weather,
 		TempC:   b.Current.TempC,
 		TempF:   CelsiusToFahrenheit(b.Current.TempC),
 		TempK:   CelsiusToKelvin(b.Current.TempC),
-		Service: "WeatherAPI",
+		Service: l.Name(),
 	}
 
 	return c, nil
@@ -11,7 +11,7 @@ func TestWeatherAPILoader_Load(t *testing.T) {
 	apikey := os.Getenv("WEATHER_APIKEY")
 
 	t.Run("WeatherAPI should return unauthorized error on invalid API KEY", func(t *testing.T) {
-		sut := NewWeatherAPILoader("invalid-key")
+		sut, _ := NewWeatherAPILoader("invalid-key")
 		ctx := context.Background()
 
 		_, err := sut.Load(ctx, "0.000", "0.000")
@@ -22,7 +22,7 @@ func TestWeatherAPILoader_Load(t *testing.T) {
 	})
 
 	t.Run("WeatherAPI should return error on invalid latitude and longitude", func(t *testing.T) {
-		sut := NewWeatherAPILoader(apikey)
+		sut, _ := NewWeatherAPILoader(apikey)
 		ctx := context.Background()
 
 		_, err := sut.Load(ctx, "", "")
@@ -33,7 +33,7 @@ func TestWeatherAPILoader_Load(t *testing.T) {
 	})
 
 	t.Run("WeatherAPI should return temperature on valid location", func(t *testing.T) {
-		sut := NewWeatherAPILoader(apikey)
+		sut, _ := NewWeatherAPILoader(apikey)
 		ctx := context.Background()
 
 		got, err := sut.Load(ctx, "-22.09967", "-43.2116")